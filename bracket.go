@@ -0,0 +1,119 @@
+package gorql
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// bracketOperators maps the operators of the bracket-style parameters to the RQL operations.
+var bracketOperators = map[string]string{
+	"eq":    "eq",
+	"ne":    "ne",
+	"gt":    "gt",
+	"gte":   "ge",
+	"ge":    "ge",
+	"lt":    "lt",
+	"lte":   "le",
+	"le":    "le",
+	"like":  "like",
+	"match": "match",
+	"in":    "in",
+	"nin":   "in",
+}
+
+// ParseBrackets constructs an AST from bracket-style query parameters. For example:
+//
+//	status[in]=a,b&price[gte]=10&sort=-price,name&fields=name,price&page[limit]=20&page[offset]=40
+//
+// A field parameter without an operator is an equality, and field[op] applies one of the operators
+// eq, ne, gt, gte (ge), lt, lte (le), like, match, in and nin, where in and nin take a comma separated
+// list of values. The parameters sort, fields (or select), page[limit] (or limit) and page[offset]
+// (or offset) set the special operations of the root node. The filter parameters are joined with
+// "and", ordered by their keys.
+//
+// Values are strings like the values of Parse, and are converted to the field types when the parser
+// has a model. The result is validated with the configuration of the parser.
+func (p *Parser) ParseBrackets(q url.Values) (root *RqlRootNode, err error) {
+	if err = p.checkLength(encodeURLValues(q)); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	root = &RqlRootNode{}
+	var args []interface{}
+	for _, k := range keys {
+		for _, v := range q[k] {
+			switch k {
+			case SortOp:
+				n := &RqlNode{Op: SortOp}
+				for _, s := range strings.Split(v, ",") {
+					n.Args = append(n.Args, s)
+				}
+				parseSort(n, root)
+			case "fields", SelectOp:
+				root.selects = append(root.selects, strings.Split(v, ",")...)
+			case "page[limit]", LimitOp:
+				root.limit = v
+			case "page[offset]", OffsetOp:
+				root.offset = v
+			default:
+				n, err := bracketNode(k, v)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, n)
+			}
+		}
+	}
+	switch len(args) {
+	case 0:
+	case 1:
+		root.Node = args[0].(*RqlNode)
+	default:
+		root.Node = &RqlNode{Op: "and", Args: args}
+	}
+	if err = p.checkComplexity(root.Node); err != nil {
+		return nil, err
+	}
+	if err = p.checkCost(context.Background(), root); err != nil {
+		return nil, err
+	}
+	if err = p.validate(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// bracketNode returns the node of a filter parameter.
+func bracketNode(key, value string) (*RqlNode, error) {
+	field, op := key, "eq"
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		if !strings.HasSuffix(key, "]") || i == 0 {
+			return nil, &ParseError{Query: key, Pos: Pos{Start: i, End: len(key)}, Err: &SyntaxError{Msg: fmt.Sprintf("malformed parameter : %s", key)}}
+		}
+		field, op = key[:i], key[i+1:len(key)-1]
+		rql, ok := bracketOperators[op]
+		if !ok {
+			return nil, &ParseError{Query: key, Pos: Pos{Start: i + 1, End: len(key) - 1}, Err: &SyntaxError{Msg: fmt.Sprintf("unknown operator %s for field %s", op, field)}}
+		}
+		if rql == "in" {
+			group := &RqlNode{Op: "group", Args: []interface{}{field}}
+			for _, v := range strings.Split(value, ",") {
+				group.Args = append(group.Args, v)
+			}
+			n := &RqlNode{Op: "in", Args: []interface{}{field, group}}
+			if op == "nin" {
+				n = &RqlNode{Op: "not", Args: []interface{}{n}}
+			}
+			return n, nil
+		}
+		op = rql
+	}
+	return &RqlNode{Op: op, Args: []interface{}{field, value}}, nil
+}
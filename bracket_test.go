@@ -0,0 +1,121 @@
+package gorql
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+type BracketTest struct {
+	Name     string      // Name of the test
+	Query    string      // Input query string
+	Model    interface{} // Input Model for query
+	Expected string      // Expected canonical RQL
+	WantErr  bool        // Expected parse error
+}
+
+var bracketTests = []BracketTest{
+	{
+		Name:     `Operators, sort and paging`,
+		Query:    `status[in]=a,b&price[gte]=10&page[limit]=20&sort=-price`,
+		Expected: `ge(price,10)&in(status,[a,b])&sort(-price)&limit(20)`,
+	},
+	{
+		Name:     `Equality without operator`,
+		Query:    `name=Smith%2C%20John&age[lt]=30`,
+		Expected: `lt(age,30)&eq(name,"Smith, John")`,
+	},
+	{
+		Name:     `Repeated parameters`,
+		Query:    `price[gt]=10&price[lte]=20&price[gt]=5`,
+		Expected: `gt(price,10)&gt(price,5)&le(price,20)`,
+	},
+	{
+		Name:     `Not in and like`,
+		Query:    `status[nin]=a,b&name[like]=jo*`,
+		Expected: `like(name,jo*)&not(in(status,[a,b]))`,
+	},
+	{
+		Name:     `Select, sort and offset`,
+		Query:    `fields=name,price&sort=name,-price&page[offset]=40&limit=10`,
+		Expected: `sort(+name,-price)&select(name,price)&limit(10,40)`,
+	},
+	{
+		Name:     `Single filter`,
+		Query:    `status[ne]=closed`,
+		Expected: `ne(status,closed)`,
+	},
+	{
+		Name:  `Model validation`,
+		Query: `age[gte]=18&name=foo&sort=-age`,
+		Model: new(struct {
+			Age  int    `rql:"filter,sort"`
+			Name string `rql:"filter"`
+		}),
+		Expected: `ge(age,18)&eq(name,foo)&sort(-age)`,
+	},
+	{
+		Name:  `Limit validation`,
+		Query: `page[limit]=1000`,
+		Model: new(struct {
+			Age int `rql:"filter"`
+		}),
+		WantErr: true,
+	},
+	{
+		Name:    `Unknown operator`,
+		Query:   `price[between]=1,2`,
+		WantErr: true,
+	},
+	{
+		Name:    `Malformed parameter`,
+		Query:   `price[gt=1`,
+		WantErr: true,
+	},
+}
+
+func TestParseBrackets(t *testing.T) {
+	for _, test := range bracketTests {
+		test.Run(t)
+	}
+}
+
+func (test BracketTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	q, err := url.ParseQuery(test.Query)
+	if err != nil {
+		t.Fatalf("(%s) Query parse error :%v\n", test.Name, err)
+	}
+	root, err := p.ParseBrackets(q)
+	if test.WantErr != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantErr, err)
+	}
+	if err != nil {
+		return
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestParseBracketsUnknownOperator(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	_, err = p.ParseBrackets(url.Values{"price[between]": {"1,2"}})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code() != CodeSyntax {
+		t.Fatalf("Expecting a syntax error, got: %v", err)
+	}
+	if expected := "price[between]\n      ^^^^^^^"; pe.Snippet() != expected {
+		t.Fatalf("Unexpected error snippet:\n%s", pe.Snippet())
+	}
+}
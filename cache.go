@@ -0,0 +1,111 @@
+package gorql
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// CacheStats holds the counters of the parse cache of a Parser.
+type CacheStats struct {
+	// Hits is the number of queries that were returned from the cache.
+	Hits uint64
+	// Misses is the number of queries that were not in the cache, or had expired.
+	Misses uint64
+	// Len is the number of queries in the cache.
+	Len int
+}
+
+// CacheStats returns the counters of the parse cache. They are all zero if the cache is disabled.
+func (p *Parser) CacheStats() CacheStats {
+	return p.cache.stats()
+}
+
+// parseCache is a least recently used cache of validated root nodes, keyed by query.
+// A nil cache is disabled.
+type parseCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	ll      *list.List
+	entries map[string]*list.Element
+	hits    uint64
+	misses  uint64
+	// now returns the current time. It is replaced in the tests.
+	now func() time.Time
+}
+
+// cacheEntry is an element of the list of the cache, with the most recently used first.
+type cacheEntry struct {
+	query string
+	root  *RqlRootNode
+	// cost is the cost of the query before the validation.
+	cost    int
+	expires time.Time
+}
+
+func newParseCache(size int, ttl time.Duration) *parseCache {
+	return &parseCache{
+		size:    size,
+		ttl:     ttl,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// get returns a copy of the cached root node of the query, and the cost of the query.
+func (c *parseCache) get(query string) (*RqlRootNode, int, bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[query]
+	if ok && c.ttl > 0 && c.now().After(el.Value.(*cacheEntry).expires) {
+		c.remove(el)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, 0, false
+	}
+	c.hits++
+	c.ll.MoveToFront(el)
+	e := el.Value.(*cacheEntry)
+	return e.root.Clone(), e.cost, true
+}
+
+// add adds a copy of the root node of the query and its cost to the cache, and evicts the
+// least recently used query if the cache is full.
+func (c *parseCache) add(query string, root *RqlRootNode, cost int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &cacheEntry{query: query, root: root.Clone(), cost: cost, expires: c.now().Add(c.ttl)}
+	if el, ok := c.entries[query]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.entries[query] = c.ll.PushFront(e)
+	if c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *parseCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).query)
+}
+
+func (c *parseCache) stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Len: c.ll.Len()}
+}
@@ -0,0 +1,145 @@
+package gorql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type cacheModel struct {
+	Name string `rql:"filter,sort"`
+	Age  int    `rql:"filter"`
+}
+
+func TestParseCache(t *testing.T) {
+	p, err := NewParser(&Config{Model: cacheModel{}, CacheSize: 2})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	parse := func(q string) *RqlRootNode {
+		root, err := p.Parse(strings.NewReader(q))
+		if err != nil {
+			t.Fatalf("Parse error of %s: %v", q, err)
+		}
+		return root
+	}
+	first := parse(`eq(age,30)&sort(-name)`)
+	first.Node.Args[0].(*RqlNode).Args[1] = 31
+	first.Sort()[0].Desc = false
+	second := parse(`eq(age,30)&sort(-name)`)
+	if s := second.String(); s != `eq(age,30)&sort(-name)` {
+		t.Fatalf("Expecting the cached root to be a copy, got %s", s)
+	}
+	if stats := p.CacheStats(); stats != (CacheStats{Hits: 1, Misses: 1, Len: 1}) {
+		t.Fatalf("Unexpected cache stats: %+v", stats)
+	}
+	parse(`eq(name,a)`)
+	parse(`eq(age,30)&sort(-name)`)
+	parse(`eq(name,b)`)
+	// eq(name,a) is the least recently used query, and was evicted.
+	parse(`eq(name,a)`)
+	if stats := p.CacheStats(); stats != (CacheStats{Hits: 2, Misses: 4, Len: 2}) {
+		t.Fatalf("Unexpected cache stats: %+v", stats)
+	}
+	if _, err = p.Parse(strings.NewReader(`eq(unknown,1)`)); err == nil {
+		t.Fatal("Expecting a validation error")
+	}
+	if _, err = p.Parse(strings.NewReader(`eq(unknown,1)`)); err == nil {
+		t.Fatal("Expecting the invalid queries not to be cached")
+	}
+}
+
+func TestParseCacheTTL(t *testing.T) {
+	p, err := NewParser(&Config{Model: cacheModel{}, CacheSize: 10, CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	now := time.Now()
+	p.cache.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if _, err = p.Parse(strings.NewReader(`eq(name,a)`)); err != nil {
+			t.Fatalf("Parse error :%v", err)
+		}
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err = p.Parse(strings.NewReader(`eq(name,a)`)); err != nil {
+		t.Fatalf("Parse error :%v", err)
+	}
+	if stats := p.CacheStats(); stats != (CacheStats{Hits: 1, Misses: 2, Len: 1}) {
+		t.Fatalf("Unexpected cache stats: %+v", stats)
+	}
+}
+
+func TestParseCacheCostBudget(t *testing.T) {
+	p, err := NewParser(&Config{Model: cacheModel{}, CacheSize: 10})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	query := `eq(name,a)&eq(age,1)`
+	if _, err = p.Parse(strings.NewReader(query)); err != nil {
+		t.Fatalf("Parse error :%v", err)
+	}
+	_, err = p.ParseContext(WithCostBudget(context.Background(), 1), strings.NewReader(query))
+	var ce *ComplexityError
+	if !errors.As(err, &ce) || ce.Limit != LimitCost {
+		t.Fatalf("Expecting the cost budget to apply to the cached queries, got: %v", err)
+	}
+}
+
+func TestParseCacheReplacedFieldCost(t *testing.T) {
+	p, err := NewParser(&Config{
+		Model: new(struct {
+			Desc string `rql:"filter,replacewith=desc_col"`
+		}),
+		FieldCosts: map[string]int{"desc": 100},
+		CacheSize:  10,
+	})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	query := `eq(desc,foo)`
+	ctx := WithCostBudget(context.Background(), 50)
+	var ce *ComplexityError
+	if _, err = p.ParseContext(ctx, strings.NewReader(query)); !errors.As(err, &ce) || ce.Value != 100 {
+		t.Fatalf("Expecting the query to be rejected on a miss, got: %v", err)
+	}
+	if _, err = p.Parse(strings.NewReader(query)); err != nil {
+		t.Fatalf("Parse error :%v", err)
+	}
+	if _, err = p.ParseContext(ctx, strings.NewReader(query)); !errors.As(err, &ce) || ce.Value != 100 {
+		t.Fatalf("Expecting the query to be rejected on a hit, got: %v", err)
+	}
+	if stats := p.CacheStats(); stats.Hits != 1 {
+		t.Fatalf("Expecting the query to be cached, got: %+v", stats)
+	}
+}
+
+func TestParseCacheConcurrency(t *testing.T) {
+	p, err := NewParser(&Config{Model: cacheModel{}, CacheSize: 2})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	queries := []string{`eq(name,a)`, `eq(name,b)`, `eq(name,c)`}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(q string) {
+			defer wg.Done()
+			root, err := p.Parse(strings.NewReader(q))
+			if err != nil {
+				t.Errorf("Parse error :%v", err)
+				return
+			}
+			if s := root.String(); s != q {
+				t.Errorf("Expecting RQL %s, got %s", q, s)
+			}
+		}(queries[i%len(queries)])
+	}
+	wg.Wait()
+	if stats := p.CacheStats(); stats.Hits+stats.Misses != 50 || stats.Len != 2 {
+		t.Fatalf("Unexpected cache stats: %+v", stats)
+	}
+}
@@ -0,0 +1,228 @@
+package gorql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MergeRule decides which of two combined queries a sort, select, limit or offset is taken from.
+type MergeRule int
+
+const (
+	// PreferFirst takes the value of the first query, or of the second one if the first is not set.
+	PreferFirst MergeRule = iota
+	// PreferSecond takes the value of the second query, or of the first one if the second is not set.
+	PreferSecond
+	// OnlyFirst takes the value of the first query, even if it is not set.
+	OnlyFirst
+	// OnlySecond takes the value of the second query, even if it is not set.
+	OnlySecond
+	// MergeBoth merges the values of both queries: the smaller limit, the larger offset, the
+	// selected fields that are in both queries, and the sort fields of the first query followed
+	// by the sort fields of the second one that are not sorted yet.
+	MergeBoth
+)
+
+// MergePolicy holds the rules of Intersect and Union for the parts of the queries that are not
+// filters. The zero value prefers the first query for all of them.
+type MergePolicy struct {
+	Limit  MergeRule
+	Offset MergeRule
+	Sort   MergeRule
+	Select MergeRule
+}
+
+// Intersect returns the query of the records that match both a and b, for example to restrict
+// a user query with a server side filter. The filters are joined with an "and" operation, and
+// the nested conjunctions are flattened. The result does not share its nodes with a and b.
+//
+//	Intersect(eq(a,1)&eq(b,2)&limit(10), or(eq(c,3),eq(d,4))&limit(5), MergePolicy{Limit: MergeBoth})
+//	=> eq(a,1)&eq(b,2)&or(eq(c,3),eq(d,4))&limit(5)
+func Intersect(a, b *RqlRootNode, policy MergePolicy) *RqlRootNode {
+	r := mergeRoots(a, b, policy)
+	r.Node = joinNodes("and", nodeOf(a), nodeOf(b))
+	return r
+}
+
+// Union returns the query of the records that match a or b. The filters are joined with an "or"
+// operation, and the nested disjunctions are flattened. Note that a query without a filter matches
+// all the records, and so does its union with any other query.
+func Union(a, b *RqlRootNode, policy MergePolicy) *RqlRootNode {
+	r := mergeRoots(a, b, policy)
+	if na, nb := nodeOf(a), nodeOf(b); na != nil && nb != nil {
+		r.Node = joinNodes("or", na, nb)
+	}
+	return r
+}
+
+func nodeOf(r *RqlRootNode) *RqlNode {
+	if r == nil {
+		return nil
+	}
+	return r.Node
+}
+
+// joinNodes returns a copy of the nodes joined with the op operation. The arguments of the
+// nodes of the same operation are inlined, instead of nesting them.
+func joinNodes(op string, nodes ...*RqlNode) *RqlNode {
+	var args []interface{}
+	for _, n := range nodes {
+		n = unwrapNode(n)
+		switch {
+		case n == nil:
+		case strings.EqualFold(n.Op, op) && len(n.Args) > 0 && hasNodeArgs(n):
+			for _, a := range n.Args {
+				args = append(args, a.(*RqlNode).Clone())
+			}
+		default:
+			args = append(args, n.Clone())
+		}
+	}
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0].(*RqlNode)
+	}
+	return &RqlNode{Op: op, Args: args}
+}
+
+// unwrapNode returns the only argument of an "and" or "or" node, like the conjunction that is left
+// by the parser when the sort or limit operations are removed from eq(a,1)&sort(+a).
+func unwrapNode(n *RqlNode) *RqlNode {
+	for n != nil && (strings.EqualFold(n.Op, "and") || strings.EqualFold(n.Op, "or")) && len(n.Args) == 1 {
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			break
+		}
+		n = c
+	}
+	return n
+}
+
+// mergeRoots returns a root node without filter, with the sort, select, limit and offset
+// of a and b merged with the policy.
+func mergeRoots(a, b *RqlRootNode, policy MergePolicy) *RqlRootNode {
+	if a == nil {
+		a = &RqlRootNode{}
+	}
+	if b == nil {
+		b = &RqlRootNode{}
+	}
+	r := &RqlRootNode{}
+	r.limit = mergeString(policy.Limit, a.limit, b.limit, func(x, y int) bool { return x < y })
+	r.offset = mergeString(policy.Offset, a.offset, b.offset, func(x, y int) bool { return x > y })
+	r.sorts = append([]Sort(nil), mergeSorts(policy.Sort, a.sorts, b.sorts)...)
+	r.selects = append([]string(nil), mergeSelects(policy.Select, a.selects, b.selects)...)
+	return r
+}
+
+// mergeString merges a limit or an offset. With MergeBoth, the value of the second query is
+// taken if it is better than the value of the first one, and both are numbers.
+func mergeString(rule MergeRule, a, b string, better func(x, y int) bool) string {
+	switch rule {
+	case PreferSecond:
+		if b != "" {
+			return b
+		}
+		return a
+	case OnlyFirst:
+		return a
+	case OnlySecond:
+		return b
+	case MergeBoth:
+		if a == "" {
+			return b
+		}
+		x, errA := strconv.Atoi(a)
+		y, errB := strconv.Atoi(b)
+		if errA == nil && errB == nil && better(y, x) {
+			return b
+		}
+		return a
+	}
+	if a != "" {
+		return a
+	}
+	return b
+}
+
+func mergeSorts(rule MergeRule, a, b []Sort) []Sort {
+	switch rule {
+	case PreferSecond:
+		if len(b) > 0 {
+			return b
+		}
+		return a
+	case OnlyFirst:
+		return a
+	case OnlySecond:
+		return b
+	case MergeBoth:
+		sorts := append([]Sort(nil), a...)
+		for _, s := range b {
+			if !sortsField(sorts, s.By) {
+				sorts = append(sorts, s)
+			}
+		}
+		return sorts
+	}
+	if len(a) > 0 {
+		return a
+	}
+	return b
+}
+
+func sortsField(sorts []Sort, field string) bool {
+	for _, s := range sorts {
+		if s.By == field {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSelects merges the selected fields. An empty list selects all the fields, so with
+// MergeBoth the fields of the other query are kept.
+func mergeSelects(rule MergeRule, a, b []string) []string {
+	switch rule {
+	case PreferSecond:
+		if len(b) > 0 {
+			return b
+		}
+		return a
+	case OnlyFirst:
+		return a
+	case OnlySecond:
+		return b
+	case MergeBoth:
+		if len(a) == 0 || len(b) == 0 {
+			return append(a, b...)
+		}
+		var selects []string
+		for _, s := range a {
+			if hasString(b, s) && !hasString(selects, s) {
+				selects = append(selects, s)
+			}
+		}
+		if len(selects) == 0 {
+			// the queries have no field in common, the first query wins instead of
+			// selecting all the fields.
+			return a
+		}
+		return selects
+	}
+	if len(a) > 0 {
+		return a
+	}
+	return b
+}
+
+func hasString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
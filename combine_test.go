@@ -0,0 +1,104 @@
+package gorql
+
+import (
+	"testing"
+)
+
+type CombineTest struct {
+	Name      string      // Name of the test
+	A         string      // Input RQL of the first query
+	B         string      // Input RQL of the second query
+	Policy    MergePolicy // Input merge policy
+	Intersect string      // Expected RQL of the intersection
+	Union     string      // Expected RQL of the union
+}
+
+var combineTests = []CombineTest{
+	{
+		Name:      `Flatten conjunctions and disjunctions`,
+		A:         `eq(a,1)&eq(b,2)`,
+		B:         `and(eq(c,3),eq(d,4))`,
+		Intersect: `eq(a,1)&eq(b,2)&eq(c,3)&eq(d,4)`,
+		Union:     `or(and(eq(a,1),eq(b,2)),and(eq(c,3),eq(d,4)))`,
+	},
+	{
+		Name:      `Nested disjunctions`,
+		A:         `or(eq(a,1),eq(b,2))`,
+		B:         `eq(c,3)|eq(d,4)`,
+		Intersect: `or(eq(a,1),eq(b,2))&or(eq(c,3),eq(d,4))`,
+		Union:     `or(eq(a,1),eq(b,2),eq(c,3),eq(d,4))`,
+	},
+	{
+		Name:      `Query without filter`,
+		A:         `sort(+a)&limit(10)`,
+		B:         `eq(b,2)&limit(5,20)`,
+		Intersect: `eq(b,2)&sort(+a)&limit(10,20)`,
+		Union:     `sort(+a)&limit(10,20)`,
+	},
+	{
+		Name:      `Prefer the second query`,
+		A:         `eq(a,1)&sort(+a)&select(a)&limit(10)`,
+		B:         `eq(b,2)&sort(-b)&offset(5)`,
+		Policy:    MergePolicy{Limit: PreferSecond, Offset: PreferSecond, Sort: PreferSecond, Select: PreferSecond},
+		Intersect: `eq(a,1)&eq(b,2)&sort(-b)&select(a)&limit(10,5)`,
+		Union:     `or(eq(a,1),eq(b,2))&sort(-b)&select(a)&limit(10,5)`,
+	},
+	{
+		Name:      `Only one of the queries`,
+		A:         `eq(a,1)&sort(+a)&select(a)&limit(10)`,
+		B:         `eq(b,2)&sort(-b)&offset(5)`,
+		Policy:    MergePolicy{Limit: OnlySecond, Offset: OnlyFirst, Sort: OnlySecond, Select: OnlySecond},
+		Intersect: `eq(a,1)&eq(b,2)&sort(-b)`,
+		Union:     `or(eq(a,1),eq(b,2))&sort(-b)`,
+	},
+	{
+		Name:      `Merge both queries`,
+		A:         `eq(a,1)&sort(+a,-c)&select(a,b,c)&limit(10,5)`,
+		B:         `eq(b,2)&sort(-b,+a)&select(c,d,a)&limit(20,30)`,
+		Policy:    MergePolicy{Limit: MergeBoth, Offset: MergeBoth, Sort: MergeBoth, Select: MergeBoth},
+		Intersect: `eq(a,1)&eq(b,2)&sort(+a,-c,-b)&select(a,c)&limit(10,30)`,
+		Union:     `or(eq(a,1),eq(b,2))&sort(+a,-c,-b)&select(a,c)&limit(10,30)`,
+	},
+	{
+		Name:      `Merge with a query that selects all the fields`,
+		A:         `eq(a,1)`,
+		B:         `eq(b,2)&select(b)&limit(20)`,
+		Policy:    MergePolicy{Limit: MergeBoth, Select: MergeBoth},
+		Intersect: `eq(a,1)&eq(b,2)&select(b)&limit(20)`,
+		Union:     `or(eq(a,1),eq(b,2))&select(b)&limit(20)`,
+	},
+}
+
+func TestCombine(t *testing.T) {
+	for _, test := range combineTests {
+		test.Run(t)
+	}
+}
+
+func (test CombineTest) Run(t *testing.T) {
+	a, b := mustParse(t, test.A), mustParse(t, test.B)
+	if s := Intersect(a, b, test.Policy).String(); s != test.Intersect {
+		t.Fatalf("(%s) Expecting intersection %s, got %s", test.Name, test.Intersect, s)
+	}
+	if s := Union(a, b, test.Policy).String(); s != test.Union {
+		t.Fatalf("(%s) Expecting union %s, got %s", test.Name, test.Union, s)
+	}
+	if s := a.String() + " " + b.String(); s != mustParse(t, test.A).String()+" "+mustParse(t, test.B).String() {
+		t.Fatalf("(%s) Expecting the queries to be unchanged, got %s", test.Name, s)
+	}
+}
+
+func TestCombineDoesNotShareNodes(t *testing.T) {
+	a := &RqlRootNode{Node: &RqlNode{Op: "AND", Args: []interface{}{
+		&RqlNode{Op: "eq", Args: []interface{}{"a", 1}},
+		&RqlNode{Op: "eq", Args: []interface{}{"b", 2}},
+	}}}
+	r := Intersect(a, nil, MergePolicy{})
+	r.Node.Args[0].(*RqlNode).Args[1] = 3
+	if s := a.String(); s != `eq(a,1)&eq(b,2)` {
+		t.Fatalf("Expecting the first query to be unchanged, got %s", s)
+	}
+	if r := Union(nil, nil, MergePolicy{}); r.Node != nil || r.String() != "" {
+		t.Fatalf("Expecting an empty query, got %s", r)
+	}
+}
@@ -0,0 +1,127 @@
+package gorql
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// numberOf returns the numeric value of a filter value. Strings are numbers if they
+// are parsable as floats, which is how the drivers treat values without a model.
+func numberOf(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return 0, false
+		}
+		return f, true
+	}
+	return 0, false
+}
+
+// compareValues compares two filter values, and reports whether they are ordered.
+// Numbers and numeric strings are compared by their value, and times chronologically.
+func compareValues(a, b interface{}) (int, bool) {
+	if x, ok := numberOf(a); ok {
+		y, ok := numberOf(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if x, ok := a.(time.Time); ok {
+		y, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x.Before(y):
+			return -1, true
+		case x.After(y):
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// equalValues reports whether two filter values are equal, and whether the answer is known.
+func equalValues(a, b interface{}) (equal bool, known bool) {
+	if c, ok := compareValues(a, b); ok {
+		return c == 0, true
+	}
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return x == y, true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			return x == y, true
+		}
+	}
+	if encodeArg(a) == encodeArg(b) {
+		return true, true
+	}
+	return false, false
+}
+
+// typedCompare compares two values of the same type, if it is ordered. Unlike compareValues,
+// strings are not ordered, even if they are numeric, as they are the values of string fields:
+// the parser converts the values of the other fields of the model to their type.
+func typedCompare(a, b interface{}) (int, bool) {
+	if _, ok := a.(string); ok {
+		return 0, false
+	}
+	if _, ok := b.(string); ok {
+		return 0, false
+	}
+	return compareValues(a, b)
+}
+
+// typedEqual reports whether two values are equal, and whether the answer is known.
+func typedEqual(a, b interface{}) (bool, bool) {
+	if c, ok := typedCompare(a, b); ok {
+		return c == 0, true
+	}
+	x, okA := a.(string)
+	y, okB := b.(string)
+	if okA && okB {
+		return x == y, true
+	}
+	if okA || okB {
+		return false, false
+	}
+	return equalValues(a, b)
+}
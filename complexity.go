@@ -0,0 +1,98 @@
+package gorql
+
+// limit returns the value of a complexity limit of the configuration, or zero if there is none.
+func (p *Parser) limit(name string) int {
+	if p.c == nil {
+		return 0
+	}
+	switch name {
+	case LimitQueryLength:
+		return p.c.MaxQueryLength
+	case LimitDepth:
+		return p.c.MaxDepth
+	case LimitNodes:
+		return p.c.MaxNodes
+	case LimitArgs:
+		return p.c.MaxArgs
+	case LimitInValues:
+		return p.c.MaxInValues
+	}
+	return 0
+}
+
+// complexityError returns the error of a query that exceeds the limit at the given span.
+func (p *Parser) complexityError(name string, value int, pos Pos) error {
+	return &ParseError{Pos: pos, Err: &ComplexityError{Limit: name, Max: p.limit(name), Value: value}}
+}
+
+// exceeds reports whether the value exceeds the limit.
+func (p *Parser) exceeds(name string, value int) bool {
+	max := p.limit(name)
+	return max > 0 && value > max
+}
+
+// checkLength checks the length of the query against MaxQueryLength.
+func (p *Parser) checkLength(query string) error {
+	if p.exceeds(LimitQueryLength, len(query)) {
+		return p.complexityError(LimitQueryLength, len(query), Pos{Start: p.limit(LimitQueryLength), End: len(query)})
+	}
+	return nil
+}
+
+// checkTokenDepth checks the nesting of the parentheses against MaxDepth, before the tokens
+// are parsed recursively.
+func (p *Parser) checkTokenDepth(ts []TokenString) error {
+	if p.limit(LimitDepth) == 0 {
+		return nil
+	}
+	depth := 0
+	for _, t := range ts {
+		switch t.t {
+		case OpeningParenthesis:
+			if depth++; p.exceeds(LimitDepth, depth) {
+				return p.complexityError(LimitDepth, depth, t.pos)
+			}
+		case ClosingParenthesis:
+			depth--
+		}
+	}
+	return nil
+}
+
+// checkComplexity checks the node tree against the MaxDepth, MaxNodes, MaxArgs and MaxInValues limits.
+func (p *Parser) checkComplexity(n *RqlNode) error {
+	if n == nil || (p.limit(LimitDepth) == 0 && p.limit(LimitNodes) == 0 && p.limit(LimitArgs) == 0 && p.limit(LimitInValues) == 0) {
+		return nil
+	}
+	count := 0
+	return p.checkNode(n, 1, &count)
+}
+
+// checkNode checks the node at the given depth and its children, and adds the number of
+// operations to count. The square brackets nodes are lists of values, and are not counted
+// as operations.
+func (p *Parser) checkNode(n *RqlNode, depth int, count *int) error {
+	if isGroupOp(n.Op) {
+		if values := len(n.Args) - 1; p.exceeds(LimitInValues, values) {
+			return p.complexityError(LimitInValues, values, n.Pos)
+		}
+		return nil
+	}
+	if p.exceeds(LimitDepth, depth) {
+		return p.complexityError(LimitDepth, depth, n.Pos)
+	}
+	if *count++; p.exceeds(LimitNodes, *count) {
+		return p.complexityError(LimitNodes, *count, n.Pos)
+	}
+	if p.exceeds(LimitArgs, len(n.Args)) {
+		return p.complexityError(LimitArgs, len(n.Args), n.Pos)
+	}
+	for _, a := range n.Args {
+		if c, ok := a.(*RqlNode); ok && c != nil {
+			if err := p.checkNode(c, depth+1, count); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
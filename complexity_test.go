@@ -0,0 +1,141 @@
+package gorql
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type ComplexityTest struct {
+	Name    string  // Name of the test
+	Config  *Config // Input limits of the parser
+	RQL     string  // Input RQL query
+	Limit   string  // Expected exceeded limit, if any
+	Value   int     // Expected value that exceeds the limit
+	Snippet string  // Expected error snippet
+}
+
+var complexityTests = []ComplexityTest{
+	{
+		Name:   `Within the limits`,
+		Config: &Config{MaxQueryLength: 100, MaxDepth: 3, MaxNodes: 4, MaxArgs: 3, MaxInValues: 3},
+		RQL:    `eq(a,1)&not(in(b,[x,y,z]))&sort(+a)&limit(10)`,
+	},
+	{
+		Name:    `Query length`,
+		Config:  &Config{MaxQueryLength: 10},
+		RQL:     `eq(a,1)&eq(b,2)`,
+		Limit:   LimitQueryLength,
+		Value:   15,
+		Snippet: "eq(a,1)&eq(b,2)\n          ^^^^^",
+	},
+	{
+		Name:    `Parentheses depth`,
+		Config:  &Config{MaxDepth: 2},
+		RQL:     `((((eq(a,1)))))`,
+		Limit:   LimitDepth,
+		Value:   3,
+		Snippet: "((((eq(a,1)))))\n  ^",
+	},
+	{
+		Name:    `Operations depth`,
+		Config:  &Config{MaxDepth: 2},
+		RQL:     `eq(a,1)&not(eq(b,2))`,
+		Limit:   LimitDepth,
+		Value:   3,
+		Snippet: "eq(a,1)&not(eq(b,2))\n            ^^^^^^^",
+	},
+	{
+		Name:    `Number of operations`,
+		Config:  &Config{MaxNodes: 3},
+		RQL:     `eq(a,1)&eq(b,2)&eq(c,3)&limit(10)`,
+		Limit:   LimitNodes,
+		Value:   4,
+		Snippet: "eq(a,1)&eq(b,2)&eq(c,3)&limit(10)\n                ^^^^^^^",
+	},
+	{
+		Name:    `Number of arguments`,
+		Config:  &Config{MaxArgs: 3},
+		RQL:     `or(eq(a,1),eq(a,2),eq(a,3),eq(a,4))`,
+		Limit:   LimitArgs,
+		Value:   4,
+		Snippet: "or(eq(a,1),eq(a,2),eq(a,3),eq(a,4))\n^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^",
+	},
+	{
+		Name:    `Number of list values`,
+		Config:  &Config{MaxInValues: 2},
+		RQL:     `in(a,[x,y,z])`,
+		Limit:   LimitInValues,
+		Value:   3,
+		Snippet: "in(a,[x,y,z])\n   ^^^^^^^^^",
+	},
+}
+
+func TestComplexityLimits(t *testing.T) {
+	for _, test := range complexityTests {
+		test.Run(t)
+	}
+}
+
+func (test ComplexityTest) Run(t *testing.T) {
+	c := *test.Config
+	c.Model = new(struct {
+		A int    `rql:"filter,sort"`
+		B string `rql:"filter"`
+		C int    `rql:"filter"`
+	})
+	p, err := NewParser(&c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	_, err = p.Parse(strings.NewReader(test.RQL))
+	if test.Limit == "" {
+		if err != nil {
+			t.Fatalf("(%s) Unexpected error: %v", test.Name, err)
+		}
+		return
+	}
+	var ce *ComplexityError
+	if !errors.As(err, &ce) || ce.Limit != test.Limit || ce.Value != test.Value {
+		t.Fatalf("(%s) Expecting the %s limit to be exceeded by %d, got: %v", test.Name, test.Limit, test.Value, err)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code() != CodeTooComplex {
+		t.Fatalf("(%s) Expecting a parse error with code %s, got: %v", test.Name, CodeTooComplex, err)
+	}
+	if s := pe.Snippet(); s != test.Snippet {
+		t.Fatalf("(%s) Unexpected error snippet:\n%s", test.Name, s)
+	}
+}
+
+func TestComplexityLimitsOfFrontEnds(t *testing.T) {
+	p, err := NewParser(&Config{
+		Model: new(struct {
+			A int `rql:"filter"`
+		}),
+		MaxDepth: 2,
+		MaxNodes: 2,
+	})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	deep := strings.Repeat("(", 10000) + "a==1" + strings.Repeat(")", 10000)
+	_, err = p.ParseFIQL(strings.NewReader(deep))
+	var ce *ComplexityError
+	if !errors.As(err, &ce) || ce.Limit != LimitDepth {
+		t.Fatalf("Expecting a depth error from ParseFIQL, got: %v", err)
+	}
+	_, err = p.ParseOData(url.Values{"$filter": {strings.Repeat("not ", 10000) + "a eq 1"}})
+	if !errors.As(err, &ce) || ce.Limit != LimitDepth {
+		t.Fatalf("Expecting a depth error from ParseOData, got: %v", err)
+	}
+	_, err = p.ParseJSON(strings.NewReader(`{"a":1,"$or":[{"a":2},{"a":3}]}`))
+	if !errors.As(err, &ce) || ce.Limit != LimitNodes {
+		t.Fatalf("Expecting a nodes error from ParseJSON, got: %v", err)
+	}
+	_, err = p.ParseBrackets(url.Values{"a[gt]": {"1"}, "a[lt]": {"5"}, "a": {"3"}})
+	if !errors.As(err, &ce) || ce.Limit != LimitNodes {
+		t.Fatalf("Expecting a nodes error from ParseBrackets, got: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/iancoleman/strcase"
 	"log"
 	"reflect"
+	"time"
 )
 
 const (
@@ -75,6 +76,55 @@ type Config struct {
 	// LimitMaxValue is the upper boundary for the limit field. User will get an error if the given value is greater
 	// than this value. It defaults to 100.
 	LimitMaxValue int
+	// AllErrors makes the parser report all the validation errors of a query in an ErrorList,
+	// instead of stopping at the first one.
+	AllErrors bool
+	// MaxQueryLength is the maximum length of a query in bytes. Zero means no limit.
+	MaxQueryLength int
+	// MaxDepth is the maximum nesting depth of the operations in a query, and of the parentheses
+	// in its text. For example, the depth of and(eq(a,1),not(eq(b,2))) is 3. Zero means no limit.
+	MaxDepth int
+	// MaxNodes is the maximum number of operations in the filter of a query. Zero means no limit.
+	MaxNodes int
+	// MaxArgs is the maximum number of arguments of an operation. Zero means no limit.
+	MaxArgs int
+	// MaxInValues is the maximum number of values in a square brackets list, like the values of
+	// in(status,[a,b,c]). Zero means no limit.
+	MaxInValues int
+	// MaxCost is the maximum cost of a query, as estimated by Parser.Cost. A budget passed to
+	// ParseContext with WithCostBudget overrides it. Zero means no limit.
+	MaxCost int
+	// OpCosts is the cost weight of each operation, e.g. {"like": 5}. The operations that are
+	// not in the map cost DefaultOpCost.
+	OpCosts map[string]int
+	// FieldCosts is the cost weight of each field, e.g. {"description": 10}. It overrides the
+	// "cost" option of the struct tag, like `rql:"filter,cost=10"`. The other fields cost
+	// DefaultFieldCost.
+	FieldCosts map[string]int
+	// WildcardCost multiplies the cost of the like and match operations whose pattern starts
+	// with a wildcard, like like(name,*son). It defaults to 10.
+	WildcardCost int
+	// QueryParam is the name of the parameter that holds the RQL query in the query strings parsed
+	// by ParseRequest and ParseRawQuery, e.g. "filter" for ?filter=eq(name,foo)&api_key=secret.
+	// When set, all the other parameters are ignored. The value of the parameter is decoded once,
+	// like a form value: a plus sign is read as a space, and %2B as a plus sign.
+	QueryParam string
+	// IgnoredParams are the names of the name=value parameters that ParseRequest and ParseRawQuery
+	// ignore, like "api_key" or "trace".
+	IgnoredParams []string
+	// IgnoreUnknownParams makes ParseRequest and ParseRawQuery ignore the name=value parameters
+	// whose name is not a field of the model.
+	IgnoreUnknownParams bool
+	// CacheSize is the number of parsed queries that Parse keeps in a least recently used cache,
+	// keyed by the query string. Parse returns a deep copy of the cached root node when the same
+	// query is parsed again, without scanning and validating it. Zero disables the cache.
+	CacheSize int
+	// CacheTTL is the duration after which a cached query expires. Zero means no expiration.
+	CacheTTL time.Duration
+	// Phrases are the phrases of the operations in the explanations of Parser.Explain, keyed by
+	// the operation. They override the DefaultPhrases, and describe the custom operations of the
+	// drivers, e.g. {"near": "{field} is near {value}"}.
+	Phrases map[string]string
 }
 
 // defaults sets the default configuration of Config.
@@ -95,9 +145,32 @@ func (c *Config) defaults() error {
 	defaultString(&c.FieldSep, DefaultFieldSep)
 	defaultInt(&c.DefaultLimit, DefaultLimit)
 	defaultInt(&c.LimitMaxValue, DefaultMaxLimit)
+	defaultInt(&c.WildcardCost, DefaultWildcardCost)
 	return nil
 }
 
+// clone returns a copy of the configuration that does not share its maps and slices with c.
+// The Model and the functions are shared, as the parser never modifies them.
+func (c *Config) clone() *Config {
+	cfg := *c
+	cfg.OpCosts = cloneMap(c.OpCosts)
+	cfg.FieldCosts = cloneMap(c.FieldCosts)
+	cfg.Phrases = cloneMap(c.Phrases)
+	cfg.IgnoredParams = append([]string(nil), c.IgnoredParams...)
+	return &cfg
+}
+
+func cloneMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
 // Column is the default function that transform field name into column name.
 // It used to convert the struct fields into lower camelcase. For example:
 //
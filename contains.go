@@ -0,0 +1,654 @@
+package gorql
+
+import (
+	"database/sql"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Containment is the answer of Contains.
+type Containment int
+
+const (
+	// ContainmentUnknown means that the analysis could not decide whether the query is contained.
+	ContainmentUnknown Containment = iota
+	// Contained means that the results of the inner query are always results of the outer query.
+	Contained
+	// NotContained means that some record can match the inner query, but not the outer query.
+	NotContained
+)
+
+func (c Containment) String() string {
+	switch c {
+	case Contained:
+		return "yes"
+	case NotContained:
+		return "no"
+	}
+	return "unknown"
+}
+
+const (
+	// maxDisjuncts is the maximum number of conjunctions of the inner query in disjunctive form.
+	maxDisjuncts = 64
+	// maxWitnesses is the maximum number of records tried to prove that a query is not contained.
+	maxWitnesses = 4096
+)
+
+// Contains reports whether the result set of the inner query is guaranteed to be a subset of the
+// result set of the outer query, for example for caching and authorization:
+//
+//	Contains(eq(tenant,5), and(eq(tenant,5),gt(price,10))) => Contained
+//	Contains(eq(tenant,5), gt(price,10))                    => NotContained
+//
+// The analysis supports the eq, ne, gt, ge, lt, le, in, and, or and not operations, and answers
+// ContainmentUnknown when it can not decide, e.g. for the like operation on different patterns.
+// The values are compared with the types of the fields of the model, so both queries must be
+// parsed by p: numbers and times are ordered, and the integers are bounded by the range of their
+// type, e.g. lt(count,1) is eq(count,0) for an unsigned count. Strings are only compared for
+// equality, as their order depends on the database collation. The predicates on the fields whose
+// type is unknown are only compared with the identical predicates. The selected fields and the
+// sort are ignored, and an outer query with a limit or an offset never contains another query
+// for sure.
+func (p *Parser) Contains(outer, inner *RqlRootNode) Containment {
+	var o, i *RqlNode
+	if outer != nil && outer.Node != nil {
+		o = pushNot(outer.Node, false)
+	}
+	if inner != nil && inner.Node != nil {
+		i = pushNot(inner.Node, false)
+	}
+	c := containment{p: p}
+	if c.implies(i, o) {
+		if outer != nil && (outer.limit != "" || outer.offset != "") {
+			return ContainmentUnknown
+		}
+		return Contained
+	}
+	if c.hasWitness(i, o) {
+		return NotContained
+	}
+	return ContainmentUnknown
+}
+
+// domainKind is the kind of the values of a field.
+type domainKind int
+
+const (
+	stringDomain domainKind = iota + 1
+	intDomain
+	floatDomain
+	boolDomain
+	timeDomain
+)
+
+// domain is the set of the values of a field. The integers are in the range [min, max].
+type domain struct {
+	kind     domainKind
+	min, max int64
+}
+
+// domainOf returns the domain of the values of a field type, or false if it is not known.
+func domainOf(t reflect.Type) (domain, bool) {
+	switch t.Kind() {
+	case reflect.String:
+		return domain{kind: stringDomain}, true
+	case reflect.Bool:
+		return domain{kind: boolDomain}, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+		return domain{kind: intDomain, min: -1 << (bits - 1), max: 1<<(bits-1) - 1}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		// the values are converted to int, so the larger unsigned values are not supported.
+		max := int64(math.MaxInt64)
+		if bits := t.Bits(); bits < 64 {
+			max = 1<<bits - 1
+		}
+		return domain{kind: intDomain, max: max}, true
+	case reflect.Float32, reflect.Float64:
+		return domain{kind: floatDomain}, true
+	case reflect.Struct:
+		switch reflect.Zero(t).Interface().(type) {
+		case sql.NullString:
+			return domain{kind: stringDomain}, true
+		case sql.NullBool:
+			return domain{kind: boolDomain}, true
+		case sql.NullInt64:
+			return domain{kind: intDomain, min: math.MinInt64, max: math.MaxInt64}, true
+		case sql.NullFloat64:
+			return domain{kind: floatDomain}, true
+		}
+		if t.ConvertibleTo(reflect.TypeOf(time.Time{})) {
+			return domain{kind: timeDomain}, true
+		}
+	}
+	return domain{}, false
+}
+
+// containment holds the fields of the model, whose types are the domains of the predicates.
+type containment struct {
+	p *Parser
+}
+
+// domain returns the domain of the field, or false if it is not a field of the model.
+func (c containment) domain(field string) (domain, bool) {
+	if c.p == nil {
+		return domain{}, false
+	}
+	f, ok := c.p.lookupField(field)
+	if !ok || f.Type == nil {
+		return domain{}, false
+	}
+	return domainOf(f.Type)
+}
+
+// literal is a predicate of a conjunction, or its negation.
+type literal struct {
+	neg bool
+	n   *RqlNode
+}
+
+func (l literal) not() literal {
+	return literal{neg: !l.neg, n: l.n}
+}
+
+// literalOf returns the literal of a predicate, where the not operations were pushed down.
+// The inequalities are negated equalities, so they compare with the negated in operations.
+func (c containment) literalOf(n *RqlNode) literal {
+	if n.Op == "not" && len(n.Args) == 1 {
+		if child, ok := n.Args[0].(*RqlNode); ok {
+			return literal{neg: true, n: child}
+		}
+	}
+	if _, _, _, ok := c.atomOf(n); ok && n.Op == "ne" {
+		return literal{neg: true, n: &RqlNode{Op: "eq", Args: n.Args, Pos: n.Pos}}
+	}
+	return literal{n: n}
+}
+
+// implies reports whether all the records that match i also match o. A nil node matches all
+// the records.
+func (c containment) implies(i, o *RqlNode) bool {
+	if o == nil {
+		return true
+	}
+	disjuncts, ok := c.disjunctsOf(i)
+	if !ok {
+		return false
+	}
+	for _, d := range disjuncts {
+		d, ok := c.withRanges(d)
+		if ok && !c.contradicts(d) && !c.conjunctionImplies(d, o) {
+			return false
+		}
+	}
+	return true
+}
+
+// disjunctsOf returns the disjunctive normal form of the node, or false if it is too large.
+func (c containment) disjunctsOf(n *RqlNode) ([][]literal, bool) {
+	if n == nil {
+		return [][]literal{nil}, true
+	}
+	children, ok := nodeArgs(n)
+	switch {
+	case n.Op == "or" && ok:
+		var out [][]literal
+		for _, child := range children {
+			ds, ok := c.disjunctsOf(child)
+			if !ok || len(out)+len(ds) > maxDisjuncts {
+				return nil, false
+			}
+			out = append(out, ds...)
+		}
+		return out, true
+	case n.Op == "and" && ok:
+		out := [][]literal{nil}
+		for _, child := range children {
+			ds, ok := c.disjunctsOf(child)
+			if !ok || len(out)*len(ds) > maxDisjuncts {
+				return nil, false
+			}
+			product := make([][]literal, 0, len(out)*len(ds))
+			for _, a := range out {
+				for _, b := range ds {
+					product = append(product, append(append([]literal(nil), a...), b...))
+				}
+			}
+			out = product
+		}
+		return out, true
+	}
+	return [][]literal{{c.literalOf(n)}}, true
+}
+
+// withRanges adds to the conjunction the values of its integer fields, if the bounds of the
+// conjunction and the range of their type leave a few of them, e.g. in(count,[0]) for lt(count,1)
+// on an unsigned count. It returns false if the bounds leave no value.
+func (c containment) withRanges(d []literal) ([]literal, bool) {
+	type interval struct{ lo, hi int64 }
+	var (
+		fields    []string
+		intervals = make(map[string]*interval)
+	)
+	for _, l := range d {
+		field, values, dom, ok := c.atomOf(l.n)
+		if l.neg || !ok || dom.kind != intDomain || l.n.Op == "in" {
+			continue
+		}
+		v, ok := integerOf(values[0])
+		if !ok {
+			continue
+		}
+		r, ok := intervals[field]
+		if !ok {
+			r = &interval{lo: dom.min, hi: dom.max}
+			intervals[field] = r
+			fields = append(fields, field)
+		}
+		if l.n.Op == "eq" {
+			if v > r.lo {
+				r.lo = v
+			}
+			if v < r.hi {
+				r.hi = v
+			}
+			continue
+		}
+		bound, inclusive, dir := c.boundOf(l.n.Op, values[0], dom)
+		if !inclusive {
+			// an exclusive bound is left at the limits of the range, e.g. lt(count,0), that no value satisfies.
+			return nil, false
+		}
+		b, _ := integerOf(bound)
+		if dir > 0 && b > r.lo {
+			r.lo = b
+		}
+		if dir < 0 && b < r.hi {
+			r.hi = b
+		}
+	}
+	for _, f := range fields {
+		r := intervals[f]
+		if r.lo > r.hi {
+			return nil, false
+		}
+		if uint64(r.hi)-uint64(r.lo) >= maxDisjuncts {
+			continue
+		}
+		group := &RqlNode{Op: "group", Args: []interface{}{f}}
+		for v := r.lo; ; v++ {
+			group.Args = append(group.Args, int(v))
+			if v == r.hi {
+				break
+			}
+		}
+		d = append(d, literal{n: &RqlNode{Op: "in", Args: []interface{}{f, group}}})
+	}
+	return d, true
+}
+
+// contradicts reports whether two literals of the conjunction can not be both satisfied.
+func (c containment) contradicts(d []literal) bool {
+	for i, a := range d {
+		for _, b := range d[i+1:] {
+			if c.literalImplies(a, b.not()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// conjunctionImplies reports whether all the records that match the conjunction also match o.
+func (c containment) conjunctionImplies(d []literal, o *RqlNode) bool {
+	children, ok := nodeArgs(o)
+	switch {
+	case o.Op == "and" && ok:
+		for _, child := range children {
+			if !c.conjunctionImplies(d, child) {
+				return false
+			}
+		}
+		return true
+	case o.Op == "or" && ok:
+		for _, child := range children {
+			if c.conjunctionImplies(d, child) {
+				return true
+			}
+		}
+		return false
+	}
+	l := c.literalOf(o)
+	for _, a := range d {
+		if c.literalImplies(a, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// literalImplies reports whether all the records that match a also match b.
+func (c containment) literalImplies(a, b literal) bool {
+	if a.neg == b.neg && a.n.String() == b.n.String() {
+		return true
+	}
+	switch {
+	case !a.neg && !b.neg:
+		return c.atomImplies(a.n, b.n)
+	case !a.neg && b.neg:
+		return c.disjoint(a.n, b.n)
+	case a.neg && b.neg:
+		return c.atomImplies(b.n, a.n)
+	}
+	return false
+}
+
+// atomOf returns the field, the value of a comparison or the values of an in operation, and the
+// domain of the field. It returns false if the node is not a comparison, or if the domain of its
+// field is not known.
+func (c containment) atomOf(n *RqlNode) (field string, values []interface{}, dom domain, ok bool) {
+	if len(n.Args) != 2 {
+		return "", nil, domain{}, false
+	}
+	if field, ok = n.Args[0].(string); !ok {
+		return "", nil, domain{}, false
+	}
+	switch n.Op {
+	case "eq", "ne", "gt", "ge", "lt", "le":
+		if _, isNode := n.Args[1].(*RqlNode); isNode {
+			return "", nil, domain{}, false
+		}
+		values = n.Args[1:]
+	case "in":
+		group, isNode := n.Args[1].(*RqlNode)
+		if !isNode || !isGroupOp(group.Op) || len(group.Args) < 1 {
+			return "", nil, domain{}, false
+		}
+		values = group.Args[1:]
+	default:
+		return "", nil, domain{}, false
+	}
+	if dom, ok = c.domain(field); !ok {
+		return "", nil, domain{}, false
+	}
+	return field, values, dom, true
+}
+
+// atomImplies reports whether all the records that match the predicate a also match b.
+func (c containment) atomImplies(a, b *RqlNode) bool {
+	fa, va, dom, ok := c.atomOf(a)
+	if !ok {
+		return false
+	}
+	fb, vb, _, ok := c.atomOf(b)
+	if !ok || fa != fb {
+		return false
+	}
+	switch a.Op {
+	case "eq", "in":
+		// a set of values implies b if all of them satisfy b.
+		for _, v := range va {
+			if sat, known := satisfiesAtom(v, b.Op, vb); !known || !sat {
+				return false
+			}
+		}
+		return len(va) > 0
+	case "ne":
+		return false
+	}
+	// a is a range, it implies a range in the same direction that is wider, or the inequality
+	// of a value that is out of the range.
+	x, incA, dirA := c.boundOf(a.Op, va[0], dom)
+	switch b.Op {
+	case "gt", "ge", "lt", "le":
+		y, incB, dirB := c.boundOf(b.Op, vb[0], dom)
+		if dirA != dirB {
+			return false
+		}
+		cmp, ok := typedCompare(x, y)
+		return ok && (cmp*dirA > 0 || (cmp == 0 && (incB || !incA)))
+	case "ne":
+		cmp, ok := typedCompare(vb[0], x)
+		return ok && (cmp*dirA < 0 || (cmp == 0 && !incA))
+	}
+	return false
+}
+
+// disjoint reports whether no record can match both predicates.
+func (c containment) disjoint(a, b *RqlNode) bool {
+	if neg, ok := negations[b.Op]; ok {
+		return c.atomImplies(a, &RqlNode{Op: neg, Args: b.Args})
+	}
+	fa, va, _, ok := c.atomOf(a)
+	if !ok || b.Op != "in" || (a.Op != "eq" && a.Op != "in") {
+		return false
+	}
+	fb, vb, _, ok := c.atomOf(b)
+	if !ok || fa != fb {
+		return false
+	}
+	for _, v := range va {
+		if sat, known := satisfiesAtom(v, "in", vb); !known || sat {
+			return false
+		}
+	}
+	return true
+}
+
+// boundOf returns the bound of a range operation, whether it is inclusive, and its direction:
+// 1 for lower bounds, and -1 for upper bounds. The bounds of integer fields are made inclusive,
+// e.g. gt(a,5) is ge(a,6), unless the bound is at the limit of the range of the field.
+func (c containment) boundOf(op string, v interface{}, dom domain) (interface{}, bool, int) {
+	dir := 1
+	if op == "lt" || op == "le" {
+		dir = -1
+	}
+	inclusive := op == "ge" || op == "le"
+	if n, ok := integerOf(v); ok && !inclusive && dom.kind == intDomain {
+		if (dir > 0 && n < dom.max) || (dir < 0 && n > dom.min) {
+			return n + int64(dir), true, dir
+		}
+	}
+	return v, inclusive, dir
+}
+
+// integerOf returns the value of an integer, that is not a string.
+func integerOf(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// satisfiesAtom reports whether the value satisfies the operation on the values, and whether
+// the answer is known.
+func satisfiesAtom(v interface{}, op string, values []interface{}) (bool, bool) {
+	switch op {
+	case "eq", "ne":
+		equal, known := typedEqual(v, values[0])
+		return equal == (op == "eq"), known
+	case "gt", "ge", "lt", "le":
+		c, ok := typedCompare(v, values[0])
+		if !ok {
+			return false, false
+		}
+		return (op == "gt" && c > 0) || (op == "ge" && c >= 0) || (op == "lt" && c < 0) || (op == "le" && c <= 0), true
+	case "in":
+		known := true
+		for _, x := range values {
+			equal, k := typedEqual(v, x)
+			if k && equal {
+				return true, true
+			}
+			known = known && k
+		}
+		return false, known
+	}
+	return false, false
+}
+
+// truth is a value of three-valued logic.
+type truth int
+
+const (
+	unknown truth = iota
+	isFalse
+	isTrue
+)
+
+// hasWitness reports whether a record can be built from the values of the queries, that
+// matches i but not o.
+func (c containment) hasWitness(i, o *RqlNode) bool {
+	candidates := make(map[string][]interface{})
+	c.collectCandidates(i, candidates)
+	c.collectCandidates(o, candidates)
+	fields := make([]string, 0, len(candidates))
+	total := 1
+	for f, values := range candidates {
+		fields = append(fields, f)
+		if total *= len(values); total > maxWitnesses {
+			return false
+		}
+	}
+	sort.Strings(fields)
+	record := make(map[string]interface{}, len(fields))
+	var try func(k int) bool
+	try = func(k int) bool {
+		if k == len(fields) {
+			return c.evalNode(i, record) == isTrue && c.evalNode(o, record) == isFalse
+		}
+		for _, v := range candidates[fields[k]] {
+			record[fields[k]] = v
+			if try(k + 1) {
+				return true
+			}
+		}
+		return false
+	}
+	return try(0)
+}
+
+// collectCandidates adds the values of the predicates of the node, and their neighbors, to
+// the candidate values of their fields.
+func (c containment) collectCandidates(n *RqlNode, candidates map[string][]interface{}) {
+	if n == nil {
+		return
+	}
+	if field, values, dom, ok := c.atomOf(n); ok {
+		for _, v := range values {
+			for _, x := range neighbors(v, dom) {
+				if !hasValue(candidates[field], x) {
+					candidates[field] = append(candidates[field], x)
+				}
+			}
+		}
+		return
+	}
+	for _, a := range n.Args {
+		if child, ok := a.(*RqlNode); ok {
+			c.collectCandidates(child, candidates)
+		}
+	}
+}
+
+// neighbors returns the value and other values of the domain, that are around the value if
+// the domain is ordered. The integers out of the range of the field are left out.
+func neighbors(v interface{}, dom domain) []interface{} {
+	if n, ok := integerOf(v); ok {
+		values := []interface{}{v}
+		if dom.kind == intDomain && n > dom.min {
+			values = append(values, n-1)
+		}
+		if dom.kind == intDomain && n < dom.max {
+			values = append(values, n+1)
+		}
+		return values
+	}
+	switch x := v.(type) {
+	case float32, float64:
+		f, _ := numberOf(x)
+		return []interface{}{v, f - 0.5, f + 0.5}
+	case time.Time:
+		return []interface{}{v, x.Add(-time.Second), x.Add(time.Second)}
+	case bool:
+		return []interface{}{v, !x}
+	case string:
+		return []interface{}{v, x + "_"}
+	}
+	return []interface{}{v}
+}
+
+func hasValue(values []interface{}, v interface{}) bool {
+	for _, x := range values {
+		if equal, known := typedEqual(x, v); known && equal {
+			return true
+		}
+	}
+	return false
+}
+
+// evalNode evaluates the node on the record, where the missing fields are unknown.
+func (c containment) evalNode(n *RqlNode, record map[string]interface{}) truth {
+	if n == nil {
+		return isTrue
+	}
+	children, ok := nodeArgs(n)
+	op := strings.ToLower(n.Op)
+	switch {
+	case (op == "and" || op == "or") && ok:
+		// the result is decided by any false argument of a conjunction, or true argument of a disjunction.
+		decisive, result := isFalse, isTrue
+		if op == "or" {
+			decisive, result = isTrue, isFalse
+		}
+		for _, child := range children {
+			switch c.evalNode(child, record) {
+			case decisive:
+				return decisive
+			case unknown:
+				result = unknown
+			}
+		}
+		return result
+	case op == "not" && ok && len(children) == 1:
+		switch c.evalNode(children[0], record) {
+		case isTrue:
+			return isFalse
+		case isFalse:
+			return isTrue
+		}
+		return unknown
+	}
+	field, values, _, ok := c.atomOf(n)
+	v, assigned := record[field]
+	if !ok || !assigned {
+		return unknown
+	}
+	sat, known := satisfiesAtom(v, op, values)
+	switch {
+	case !known:
+		return unknown
+	case sat:
+		return isTrue
+	}
+	return isFalse
+}
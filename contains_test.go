@@ -0,0 +1,205 @@
+package gorql
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type containsModel struct {
+	Tenant  int       `rql:"filter"`
+	Price   float64   `rql:"filter,sort"`
+	Status  string    `rql:"filter"`
+	Name    string    `rql:"filter"`
+	Created time.Time `rql:"filter"`
+	Count   uint      `rql:"filter"`
+	Level   int8      `rql:"filter"`
+}
+
+type ContainsTest struct {
+	Name     string      // Name of the test
+	Outer    string      // Input RQL of the outer query
+	Inner    string      // Input RQL of the inner query
+	Expected Containment // Expected answer
+}
+
+var containsTests = []ContainsTest{
+	{
+		Name:     `Conjunction of the outer filter`,
+		Outer:    `eq(tenant,5)`,
+		Inner:    `and(eq(tenant,5),gt(price,10))`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Missing predicate`,
+		Outer:    `eq(tenant,5)`,
+		Inner:    `gt(price,10)`,
+		Expected: NotContained,
+	},
+	{
+		Name:     `Different equality`,
+		Outer:    `eq(tenant,5)`,
+		Inner:    `eq(tenant,6)&gt(price,10)`,
+		Expected: NotContained,
+	},
+	{
+		Name:     `Narrower range`,
+		Outer:    `ge(price,10)&lt(price,100)`,
+		Inner:    `gt(price,20)&le(price,50)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Wider range`,
+		Outer:    `gt(price,10)`,
+		Inner:    `ge(price,10)`,
+		Expected: NotContained,
+	},
+	{
+		Name:     `Integer bounds`,
+		Outer:    `ge(tenant,6)`,
+		Inner:    `gt(tenant,5)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Values of in`,
+		Outer:    `in(status,[a,b,c])`,
+		Inner:    `in(status,[a,c])|eq(status,b)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Value out of in`,
+		Outer:    `in(status,[a,b])`,
+		Inner:    `in(status,[a,d])`,
+		Expected: NotContained,
+	},
+	{
+		Name:     `Inequalities`,
+		Outer:    `ne(status,a)`,
+		Inner:    `eq(status,b)|not(in(status,[a,c]))`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Disjunction of the outer filter`,
+		Outer:    `or(eq(tenant,5),lt(price,10))`,
+		Inner:    `lt(price,5)&eq(status,x)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Negated disjunction`,
+		Outer:    `not(or(eq(status,a),eq(status,b)))`,
+		Inner:    `eq(status,c)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Contradicting inner filter`,
+		Outer:    `eq(tenant,5)`,
+		Inner:    `gt(price,10)&lt(price,5)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Times`,
+		Outer:    `gt(created,2020-01-01T00:00:00Z)`,
+		Inner:    `eq(created,2021-01-01T00:00:00Z)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Strings are not ordered`,
+		Outer:    `gt(name,a)`,
+		Inner:    `gt(name,b)`,
+		Expected: ContainmentUnknown,
+	},
+	{
+		Name:     `Same like pattern`,
+		Outer:    `like(name,jo*)`,
+		Inner:    `like(name,jo*)&eq(tenant,1)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Different like patterns`,
+		Outer:    `like(name,jo*)`,
+		Inner:    `like(name,john*)`,
+		Expected: ContainmentUnknown,
+	},
+	{
+		Name:     `Outer query without filter`,
+		Outer:    `sort(+price)`,
+		Inner:    `eq(tenant,5)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Inner query without filter`,
+		Outer:    `eq(tenant,5)`,
+		Inner:    `limit(10)`,
+		Expected: NotContained,
+	},
+	{
+		Name:     `Unsigned integer bounds`,
+		Outer:    `eq(count,0)`,
+		Inner:    `lt(count,1)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Unsigned integer out of range`,
+		Outer:    `ge(count,0)`,
+		Inner:    `gt(count,5)|lt(count,3)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Unsigned integer witness`,
+		Outer:    `eq(count,0)`,
+		Inner:    `lt(count,2)`,
+		Expected: NotContained,
+	},
+	{
+		Name:     `Integer at the limit of its type`,
+		Outer:    `eq(tenant,5)`,
+		Inner:    `gt(level,127)`,
+		Expected: Contained,
+	},
+	{
+		Name:     `Outer query with a limit`,
+		Outer:    `eq(tenant,5)&limit(10)`,
+		Inner:    `eq(tenant,5)`,
+		Expected: ContainmentUnknown,
+	},
+}
+
+func TestContains(t *testing.T) {
+	p, err := NewParser(&Config{Model: containsModel{}})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	for _, test := range containsTests {
+		outer, err := p.Parse(strings.NewReader(test.Outer))
+		if err != nil {
+			t.Fatalf("(%s) Parse error :%v", test.Name, err)
+		}
+		inner, err := p.Parse(strings.NewReader(test.Inner))
+		if err != nil {
+			t.Fatalf("(%s) Parse error :%v", test.Name, err)
+		}
+		if c := p.Contains(outer, inner); c != test.Expected {
+			t.Fatalf("(%s) Expecting %v, got %v", test.Name, test.Expected, c)
+		}
+	}
+}
+
+func TestContainsUnknownTypes(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	for _, q := range [][2]string{
+		{`eq(count,0)`, `lt(count,1)`},
+		{`gt(price,10)`, `gt(price,20)`},
+		{`eq(tenant,5)`, `gt(price,10)`},
+	} {
+		outer, inner := mustParse(t, q[0]), mustParse(t, q[1])
+		if c := p.Contains(outer, inner); c != ContainmentUnknown {
+			t.Fatalf("Expecting unknown for %s in %s without a model, got %v", q[1], q[0], c)
+		}
+	}
+	if c := p.Contains(mustParse(t, `eq(tenant,5)`), mustParse(t, `eq(tenant,5)&gt(price,10)`)); c != Contained {
+		t.Fatalf("Expecting the identical predicates to be contained, got %v", c)
+	}
+}
@@ -0,0 +1,164 @@
+package gorql
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	DefaultOpCost       = 1
+	DefaultFieldCost    = 1
+	DefaultWildcardCost = 10
+)
+
+// costBudgetKey is the context key of the cost budget.
+type costBudgetKey struct{}
+
+// WithCostBudget returns a copy of ctx that carries the maximum cost of the queries parsed
+// with ParseContext. It overrides Config.MaxCost for that call. A budget of zero means no limit.
+func WithCostBudget(ctx context.Context, budget int) context.Context {
+	return context.WithValue(ctx, costBudgetKey{}, budget)
+}
+
+// CostBudget returns the cost budget of ctx, if it has one.
+func CostBudget(ctx context.Context) (int, bool) {
+	budget, ok := ctx.Value(costBudgetKey{}).(int)
+	return budget, ok
+}
+
+// Cost returns the estimated cost of the query, with the weights of the parser configuration.
+// The cost of a filter operation on a field is the cost of the operation multiplied by the cost
+// of the field and by the number of its values, e.g. the cost of in(a,[x,y,z]) is 3 with the
+// default weights. The like and match operations whose pattern starts with a wildcard are
+// multiplied by Config.WildcardCost. The logical operations cost the sum of their operands, and
+// each sort field costs the "sort" operation multiplied by the cost of the field.
+func (p *Parser) Cost(root *RqlRootNode) int {
+	if root == nil {
+		return 0
+	}
+	cost := p.nodeCost(root.Node)
+	for _, s := range root.Sort() {
+		cost += p.opCost(SortOp) * p.fieldCost(s.By)
+	}
+	return cost
+}
+
+// nodeCost returns the cost of the node and its children.
+func (p *Parser) nodeCost(n *RqlNode) int {
+	if n == nil {
+		return 0
+	}
+	switch strings.ToLower(n.Op) {
+	case "and", "or", "not":
+		cost := 0
+		for _, a := range n.Args {
+			if c, ok := a.(*RqlNode); ok {
+				cost += p.nodeCost(c)
+			}
+		}
+		return cost
+	}
+	field, ok := "", false
+	if len(n.Args) > 0 {
+		field, ok = n.Args[0].(string)
+	}
+	if !ok {
+		// an operation without a field, like a custom operation of a driver.
+		cost := p.opCost(n.Op)
+		for _, a := range n.Args {
+			if c, ok := a.(*RqlNode); ok {
+				cost += p.nodeCost(c)
+			}
+		}
+		return cost
+	}
+	values := 0
+	for _, a := range n.Args[1:] {
+		if c, ok := a.(*RqlNode); ok && isGroupOp(c.Op) {
+			values += len(c.Args) - 1
+		} else {
+			values++
+		}
+	}
+	if values == 0 {
+		values = 1
+	}
+	cost := p.opCost(n.Op) * p.fieldCost(field) * values
+	if isPatternOp(n.Op) && len(n.Args) > 1 {
+		if s, ok := n.Args[1].(string); ok && strings.HasPrefix(s, "*") {
+			cost *= p.wildcardCost()
+		}
+	}
+	return cost
+}
+
+// isPatternOp reports whether the operation matches its values against a pattern.
+func isPatternOp(op string) bool {
+	op = strings.ToLower(op)
+	return op == "like" || op == "match"
+}
+
+// opCost returns the weight of the operation.
+func (p *Parser) opCost(op string) int {
+	if p.c != nil {
+		if cost, ok := p.c.OpCosts[op]; ok {
+			return cost
+		}
+	}
+	return DefaultOpCost
+}
+
+// fieldCost returns the weight of the field. The field may be referenced by its name, or by
+// the name that replaces it after the validation.
+func (p *Parser) fieldCost(name string) int {
+	if p.c == nil {
+		return DefaultFieldCost
+	}
+	f, found := p.lookupField(name)
+	if found {
+		// FieldCosts is keyed by the name of the field in the query.
+		name = f.Name
+	}
+	if cost, ok := p.c.FieldCosts[name]; ok {
+		return cost
+	}
+	if found && f.Cost > 0 {
+		return f.Cost
+	}
+	return DefaultFieldCost
+}
+
+// wildcardCost returns the multiplier of the patterns that start with a wildcard.
+func (p *Parser) wildcardCost() int {
+	if p.c == nil {
+		return DefaultWildcardCost
+	}
+	return p.c.WildcardCost
+}
+
+// checkCost checks the cost of the query against the budget of ctx, or Config.MaxCost if ctx
+// has no budget.
+func (p *Parser) checkCost(ctx context.Context, root *RqlRootNode) error {
+	return p.checkBudget(ctx, root, p.Cost(root))
+}
+
+// checkBudget checks the given cost of the query against the budget of ctx, or Config.MaxCost
+// if ctx has no budget. The cached queries are checked with the cost of their tree before the
+// validation, because the validation replaces the field names that the costs are keyed by.
+func (p *Parser) checkBudget(ctx context.Context, root *RqlRootNode, cost int) error {
+	budget, ok := CostBudget(ctx)
+	if !ok && p.c != nil {
+		budget = p.c.MaxCost
+	}
+	if budget <= 0 {
+		return nil
+	}
+	if cost > budget {
+		var pos Pos
+		if root.Node != nil {
+			pos = root.Node.Pos
+		}
+		return &ParseError{Pos: pos, Err: &ComplexityError{Limit: LimitCost, Max: budget, Value: cost}}
+	}
+	return nil
+}
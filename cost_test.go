@@ -0,0 +1,152 @@
+package gorql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type CostTest struct {
+	Name     string  // Name of the test
+	Config   *Config // Input weights of the parser
+	RQL      string  // Input RQL query
+	Expected int     // Expected cost of the query
+}
+
+type costModel struct {
+	ID          int    `rql:"filter,sort"`
+	Status      string `rql:"filter"`
+	Description string `rql:"filter,sort,cost=5"`
+	Name        string `rql:"filter,column=full_name"`
+}
+
+var costTests = []CostTest{
+	{
+		Name:     `Default weights`,
+		RQL:      `eq(id,1)&in(status,[a,b,c])`,
+		Expected: 4,
+	},
+	{
+		Name:     `Field cost of the struct tag`,
+		RQL:      `or(eq(id,1),eq(description,foo))`,
+		Expected: 6,
+	},
+	{
+		Name:     `Leading wildcard`,
+		RQL:      `like(description,*foo)|like(description,foo*)`,
+		Expected: 55,
+	},
+	{
+		Name:     `Operation and field weights of the config`,
+		Config:   &Config{OpCosts: map[string]int{"match": 3}, FieldCosts: map[string]int{"description": 2}},
+		RQL:      `match(description,foo)&not(eq(status,a))`,
+		Expected: 7,
+	},
+	{
+		Name:     `Wildcard weight of the config`,
+		Config:   &Config{WildcardCost: 2},
+		RQL:      `match(full_name,*foo)`,
+		Expected: 2,
+	},
+	{
+		Name:     `Sort fields`,
+		Config:   &Config{OpCosts: map[string]int{"sort": 2}},
+		RQL:      `eq(id,1)&sort(+id,-description)&limit(10)`,
+		Expected: 13,
+	},
+	{
+		Name:     `Empty query`,
+		RQL:      ``,
+		Expected: 0,
+	},
+}
+
+func TestCost(t *testing.T) {
+	for _, test := range costTests {
+		test.Run(t)
+	}
+}
+
+func (test CostTest) Run(t *testing.T) {
+	c := Config{}
+	if test.Config != nil {
+		c = *test.Config
+	}
+	c.Model = costModel{}
+	p, err := NewParser(&c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Parse error :%v\n", test.Name, err)
+	}
+	if cost := p.Cost(root); cost != test.Expected {
+		t.Fatalf("(%s) Expecting cost %d, got %d", test.Name, test.Expected, cost)
+	}
+}
+
+func TestCostBudget(t *testing.T) {
+	p, err := NewParser(&Config{Model: costModel{}, MaxCost: 10})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	query := `eq(id,1)&like(description,*foo)`
+	_, err = p.Parse(strings.NewReader(query))
+	var ce *ComplexityError
+	if !errors.As(err, &ce) || ce.Limit != LimitCost || ce.Max != 10 || ce.Value != 51 {
+		t.Fatalf("Expecting the cost limit to be exceeded, got: %v", err)
+	}
+	if _, err = p.ParseContext(WithCostBudget(context.Background(), 100), strings.NewReader(query)); err != nil {
+		t.Fatalf("Expecting the budget of the context to override the config, got: %v", err)
+	}
+	_, err = p.ParseContext(WithCostBudget(context.Background(), 1), strings.NewReader(`eq(id,1)&eq(status,a)`))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code() != CodeTooComplex {
+		t.Fatalf("Expecting the budget of the context to be exceeded, got: %v", err)
+	}
+	if _, err = p.ParseContext(WithCostBudget(context.Background(), 0), strings.NewReader(query)); err != nil {
+		t.Fatalf("Expecting a zero budget to disable the limit, got: %v", err)
+	}
+	if _, err = p.ParseFIQL(strings.NewReader(`description=like=*foo`)); !errors.As(err, &ce) || ce.Limit != LimitCost {
+		t.Fatalf("Expecting the cost limit to apply to ParseFIQL, got: %v", err)
+	}
+}
+
+func TestCostTagOption(t *testing.T) {
+	_, err := NewParser(&Config{Model: new(struct {
+		A int `rql:"filter,cost=high"`
+	})})
+	if err == nil {
+		t.Fatal("Expecting an error for an invalid cost option")
+	}
+	_, err = NewParser(&Config{Model: new(struct {
+		A int `rql:"filter,cost=0"`
+	})})
+	if err == nil {
+		t.Fatal("Expecting an error for a zero cost option")
+	}
+}
+
+func TestCostValidatedTree(t *testing.T) {
+	p, err := NewParser(&Config{
+		Model: new(struct {
+			Desc string `rql:"filter,sort,replacewith=desc_col"`
+		}),
+		FieldCosts: map[string]int{"desc": 100},
+	})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	root, err := p.Parse(strings.NewReader(`eq(desc,foo)`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if name := root.Node.Args[0]; name != "desc_col" {
+		t.Fatalf("Expecting the field to be replaced, got %v", name)
+	}
+	if cost := p.Cost(root); cost != 100 {
+		t.Fatalf("Expecting the cost of the validated tree to be 100, got %d", cost)
+	}
+}
@@ -0,0 +1,352 @@
+package gorql
+
+import (
+	"strings"
+)
+
+// ChangeKind is the kind of a change between two queries.
+type ChangeKind int
+
+const (
+	// Added is a part of the second query that is not in the first one.
+	Added ChangeKind = iota + 1
+	// Removed is a part of the first query that is not in the second one.
+	Removed
+	// Changed is a part of both queries that has a different value.
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// QueryDiff is the structural difference between two queries, as returned by Diff.
+type QueryDiff struct {
+	// Predicates are the changes of the predicates of the top level conjunction of the filters.
+	Predicates []PredicateChange
+	// Sorts are the changes of the sort keys.
+	Sorts []SortChange
+	// Selects are the added and removed selected fields.
+	Selects []SelectChange
+	// Limit and Offset are the changes of the limit and offset, or nil if they are unchanged.
+	Limit  *ValueChange
+	Offset *ValueChange
+}
+
+// PredicateChange is an added, removed or changed predicate of the filter.
+type PredicateChange struct {
+	Kind ChangeKind
+	// Field is the field of the predicate, or empty if it has no single field, like an "or"
+	// operation on different fields.
+	Field string
+	// From is the predicate of the first query, and To the predicate of the second one.
+	// From is nil for an added predicate, and To for a removed predicate.
+	From, To *RqlNode
+}
+
+func (c PredicateChange) String() string {
+	switch c.Kind {
+	case Added:
+		return "added " + c.To.String()
+	case Removed:
+		return "removed " + c.From.String()
+	}
+	return "changed " + c.From.String() + " to " + c.To.String()
+}
+
+// SortChange is an added, removed or changed sort key. A key is changed if its direction changed,
+// or its position among the keys of both sorts. The indexes are the positions of the key in the
+// sorts, or -1.
+type SortChange struct {
+	Kind      ChangeKind
+	From, To  Sort
+	FromIndex int
+	ToIndex   int
+}
+
+func (c SortChange) String() string {
+	switch c.Kind {
+	case Added:
+		return "added sort " + encodeSort(c.To)
+	case Removed:
+		return "removed sort " + encodeSort(c.From)
+	}
+	return "changed sort " + encodeSort(c.From) + " to " + encodeSort(c.To)
+}
+
+// SelectChange is an added or removed selected field.
+type SelectChange struct {
+	Kind  ChangeKind
+	Field string
+}
+
+func (c SelectChange) String() string {
+	return c.Kind.String() + " select " + c.Field
+}
+
+// ValueChange is the change of the limit or the offset. An empty value is not set.
+type ValueChange struct {
+	Kind     ChangeKind
+	From, To string
+}
+
+// describe returns the description of the change of the named value.
+func (c *ValueChange) describe(name string) string {
+	switch c.Kind {
+	case Added:
+		return "added " + name + " " + c.To
+	case Removed:
+		return "removed " + name + " " + c.From
+	}
+	return "changed " + name + " " + c.From + " to " + c.To
+}
+
+// Empty reports whether the queries are equivalent.
+func (d *QueryDiff) Empty() bool {
+	return len(d.Predicates) == 0 && len(d.Sorts) == 0 && len(d.Selects) == 0 && d.Limit == nil && d.Offset == nil
+}
+
+// Changes returns the descriptions of the changes, e.g. "added eq(status,a)" or
+// "changed sort +name to -name".
+func (d *QueryDiff) Changes() []string {
+	var changes []string
+	for _, c := range d.Predicates {
+		changes = append(changes, c.String())
+	}
+	for _, c := range d.Sorts {
+		changes = append(changes, c.String())
+	}
+	for _, c := range d.Selects {
+		changes = append(changes, c.String())
+	}
+	if d.Limit != nil {
+		changes = append(changes, d.Limit.describe(LimitOp))
+	}
+	if d.Offset != nil {
+		changes = append(changes, d.Offset.describe(OffsetOp))
+	}
+	return changes
+}
+
+// Diff returns the changes from the query a to the query b. The predicates are the arguments
+// of the top level conjunctions of the filters, that are compared regardless of their order, and
+// of the order of the nested and/or arguments. The predicates of b that are not in a are paired
+// with the removed predicates of a on the same field, and reported as changed, e.g. gt(price,10)
+// changed to gt(price,20). The predicates are the nodes of a and b, that must not be modified.
+func Diff(a, b *RqlRootNode) *QueryDiff {
+	if a == nil {
+		a = &RqlRootNode{}
+	}
+	if b == nil {
+		b = &RqlRootNode{}
+	}
+	return &QueryDiff{
+		Predicates: diffPredicates(conjunctsOf(a.Node), conjunctsOf(b.Node)),
+		Sorts:      diffSorts(a.sorts, b.sorts),
+		Selects:    diffSelects(a.selects, b.selects),
+		Limit:      diffValue(a.limit, b.limit),
+		Offset:     diffValue(a.offset, b.offset),
+	}
+}
+
+// conjunctsOf returns the arguments of the top level conjunction of the filter, or the filter.
+func conjunctsOf(n *RqlNode) []*RqlNode {
+	n = unwrapNode(n)
+	if n == nil {
+		return nil
+	}
+	if !strings.EqualFold(n.Op, "and") || len(n.Args) == 0 || !hasNodeArgs(n) {
+		return []*RqlNode{n}
+	}
+	var out []*RqlNode
+	for _, a := range n.Args {
+		out = append(out, conjunctsOf(a.(*RqlNode))...)
+	}
+	return out
+}
+
+func diffPredicates(from, to []*RqlNode) []PredicateChange {
+	// the identical predicates are matched first, whatever their positions.
+	fromKeys, toKeys := make(map[string]int), make(map[string]int)
+	for _, n := range from {
+		fromKeys[templateOf(n, true)]++
+	}
+	for _, n := range to {
+		toKeys[templateOf(n, true)]++
+	}
+	var removed, added []*RqlNode
+	for _, n := range from {
+		if key := templateOf(n, true); toKeys[key] > 0 {
+			toKeys[key]--
+		} else {
+			removed = append(removed, n)
+		}
+	}
+	for _, n := range to {
+		if key := templateOf(n, true); fromKeys[key] > 0 {
+			fromKeys[key]--
+		} else {
+			added = append(added, n)
+		}
+	}
+	// the removed and added predicates on the same field are paired, preferring the
+	// predicates of the same operation, and then of the same kind, e.g. gt and ge.
+	var changes []PredicateChange
+	paired := make([]bool, len(added))
+	for _, r := range removed {
+		field := predicateField(r)
+		best, bestScore := -1, 0
+		for i, n := range added {
+			if paired[i] || field == "" || predicateField(n) != field {
+				continue
+			}
+			score := 1
+			if strings.EqualFold(n.Op, r.Op) {
+				score = 3
+			} else if opKind(n.Op) == opKind(r.Op) {
+				score = 2
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			changes = append(changes, PredicateChange{Kind: Removed, Field: field, From: r})
+			continue
+		}
+		paired[best] = true
+		changes = append(changes, PredicateChange{Kind: Changed, Field: field, From: r, To: added[best]})
+	}
+	for i, n := range added {
+		if !paired[i] {
+			changes = append(changes, PredicateChange{Kind: Added, Field: predicateField(n), To: n})
+		}
+	}
+	return changes
+}
+
+// opKind returns the kind of an operation: the lower bounds, the upper bounds, the sets of
+// values, or the operation itself.
+func opKind(op string) string {
+	switch op = strings.ToLower(op); op {
+	case "gt", "ge":
+		return "lower"
+	case "lt", "le":
+		return "upper"
+	case "eq", "in":
+		return "values"
+	}
+	return op
+}
+
+// predicateField returns the field of a predicate: the first argument of the operation if it is
+// a string, or the field of all the predicates of an and/or/not operation.
+func predicateField(n *RqlNode) string {
+	n = unwrapNode(n)
+	if n == nil || len(n.Args) == 0 {
+		return ""
+	}
+	if field, ok := n.Args[0].(string); ok {
+		return field
+	}
+	if !hasNodeArgs(n) {
+		return ""
+	}
+	field := ""
+	for _, a := range n.Args {
+		f := predicateField(a.(*RqlNode))
+		if f == "" || (field != "" && f != field) {
+			return ""
+		}
+		field = f
+	}
+	return field
+}
+
+// diffSorts returns the changes of the sort keys. A key is moved if its position among the
+// keys of both sorts changed, so removing the first key does not move the other ones.
+func diffSorts(from, to []Sort) []SortChange {
+	var common []string
+	for _, s := range from {
+		if sortIndex(to, s.By) >= 0 {
+			common = append(common, s.By)
+		}
+	}
+	var changes []SortChange
+	k := 0
+	for i, s := range from {
+		j := sortIndex(to, s.By)
+		if j < 0 {
+			changes = append(changes, SortChange{Kind: Removed, From: s, FromIndex: i, ToIndex: -1})
+			continue
+		}
+		if to[j] != s || commonIndex(to, common, s.By) != k {
+			changes = append(changes, SortChange{Kind: Changed, From: s, To: to[j], FromIndex: i, ToIndex: j})
+		}
+		k++
+	}
+	for j, s := range to {
+		if sortIndex(from, s.By) < 0 {
+			changes = append(changes, SortChange{Kind: Added, To: s, FromIndex: -1, ToIndex: j})
+		}
+	}
+	return changes
+}
+
+// commonIndex returns the position of the field among the sort keys that are common to both sorts.
+func commonIndex(sorts []Sort, common []string, field string) int {
+	k := 0
+	for _, s := range sorts {
+		if s.By == field {
+			return k
+		}
+		if hasString(common, s.By) {
+			k++
+		}
+	}
+	return -1
+}
+
+func sortIndex(sorts []Sort, field string) int {
+	for i, s := range sorts {
+		if s.By == field {
+			return i
+		}
+	}
+	return -1
+}
+
+func diffSelects(from, to []string) []SelectChange {
+	var changes []SelectChange
+	for _, f := range from {
+		if !hasString(to, f) {
+			changes = append(changes, SelectChange{Kind: Removed, Field: f})
+		}
+	}
+	for _, f := range to {
+		if !hasString(from, f) {
+			changes = append(changes, SelectChange{Kind: Added, Field: f})
+		}
+	}
+	return changes
+}
+
+func diffValue(from, to string) *ValueChange {
+	switch {
+	case from == to:
+		return nil
+	case from == "":
+		return &ValueChange{Kind: Added, To: to}
+	case to == "":
+		return &ValueChange{Kind: Removed, From: from}
+	}
+	return &ValueChange{Kind: Changed, From: from, To: to}
+}
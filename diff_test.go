@@ -0,0 +1,80 @@
+package gorql
+
+import (
+	"reflect"
+	"testing"
+)
+
+type DiffTest struct {
+	Name     string   // Name of the test
+	A        string   // Input RQL of the first query
+	B        string   // Input RQL of the second query
+	Expected []string // Expected descriptions of the changes
+}
+
+var diffTests = []DiffTest{
+	{
+		Name: `Reordered conjunction`,
+		A:    `eq(a,1)&or(eq(b,2),eq(c,3))&sort(+a)&limit(10)`,
+		B:    `or(eq(c,3),eq(b,2))&and(eq(a,1))&sort(+a)&limit(10)`,
+	},
+	{
+		Name:     `Added and removed predicates`,
+		A:        `eq(a,1)&eq(b,2)`,
+		B:        `eq(b,2)&in(status,[x,y])`,
+		Expected: []string{`removed eq(a,1)`, `added in(status,[x,y])`},
+	},
+	{
+		Name:     `Changed predicates`,
+		A:        `gt(price,10)&lt(price,100)&eq(a,1)`,
+		B:        `eq(a,1)&lt(price,50)&ge(price,10)`,
+		Expected: []string{`changed gt(price,10) to ge(price,10)`, `changed lt(price,100) to lt(price,50)`},
+	},
+	{
+		Name:     `Sort keys`,
+		A:        `sort(+name,-age,+id)`,
+		B:        `sort(-createdAt,-name,-age)`,
+		Expected: []string{`changed sort +name to -name`, `removed sort +id`, `added sort -createdAt`},
+	},
+	{
+		Name:     `Moved sort key`,
+		A:        `sort(+a,+b)`,
+		B:        `sort(+b,+a)`,
+		Expected: []string{`changed sort +a to +a`, `changed sort +b to +b`},
+	},
+	{
+		Name:     `Selects, limit and offset`,
+		A:        `select(a,b)&limit(10,20)`,
+		B:        `select(b,c)&offset(40)`,
+		Expected: []string{`removed select a`, `added select c`, `removed limit 10`, `changed offset 20 to 40`},
+	},
+}
+
+func TestDiff(t *testing.T) {
+	for _, test := range diffTests {
+		d := Diff(mustParse(t, test.A), mustParse(t, test.B))
+		if changes := d.Changes(); !reflect.DeepEqual(changes, test.Expected) {
+			t.Fatalf("(%s) Expecting changes %q, got %q", test.Name, test.Expected, changes)
+		}
+		if d.Empty() != (len(test.Expected) == 0) {
+			t.Fatalf("(%s) Unexpected empty diff: %v", test.Name, d.Empty())
+		}
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	d := Diff(mustParse(t, `eq(a,1)&sort(+name)`), mustParse(t, `eq(a,1)&or(eq(status,x),eq(status,y))&sort(-createdAt)`))
+	if len(d.Predicates) != 1 || d.Predicates[0].Kind != Added || d.Predicates[0].Field != "status" || d.Predicates[0].From != nil {
+		t.Fatalf("Unexpected predicate changes: %+v", d.Predicates)
+	}
+	expected := []SortChange{
+		{Kind: Removed, From: Sort{By: "name"}, FromIndex: 0, ToIndex: -1},
+		{Kind: Added, To: Sort{By: "createdAt", Desc: true}, FromIndex: -1, ToIndex: 0},
+	}
+	if !reflect.DeepEqual(d.Sorts, expected) {
+		t.Fatalf("Unexpected sort changes: %+v", d.Sorts)
+	}
+	if d := Diff(nil, mustParse(t, `eq(a,1)`)); len(d.Predicates) != 1 || d.Predicates[0].Kind != Added {
+		t.Fatalf("Unexpected changes from an empty query: %q", d.Changes())
+	}
+}
@@ -0,0 +1,250 @@
+package gorql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// String encodes the root node into its canonical RQL form. The filter comes first,
+// followed by the sort, select, limit and offset operations. For example:
+//
+//	eq(foo,42)&or(gt(price,10),lt(price,5))&sort(+price,-name)&select(foo,price)&limit(10,20)
+//
+// The output can be parsed back with Parser.Parse. Note that the values of a validated root node
+// are encoded as converted by the parser, and the fields as replaced by the "replacewith" option.
+func (r *RqlRootNode) String() string {
+	if r == nil {
+		return ""
+	}
+	var parts []string
+	if r.Node != nil {
+		parts = appendConjuncts(parts, r.Node)
+	}
+	if len(r.sorts) > 0 {
+		sorts := make([]string, len(r.sorts))
+		for i, s := range r.sorts {
+			sorts[i] = encodeSort(s)
+		}
+		parts = append(parts, SortOp+"("+strings.Join(sorts, ",")+")")
+	}
+	if len(r.selects) > 0 {
+		selects := make([]string, len(r.selects))
+		for i, s := range r.selects {
+			selects[i] = encodeString(s)
+		}
+		parts = append(parts, SelectOp+"("+strings.Join(selects, ",")+")")
+	}
+	switch {
+	case r.limit != "" && r.offset != "":
+		parts = append(parts, LimitOp+"("+encodeString(r.limit)+","+encodeString(r.offset)+")")
+	case r.limit != "":
+		parts = append(parts, LimitOp+"("+encodeString(r.limit)+")")
+	case r.offset != "":
+		parts = append(parts, OffsetOp+"("+encodeString(r.offset)+")")
+	}
+	return strings.Join(parts, "&")
+}
+
+// appendConjuncts appends the encoded top level conjunctions of the node. The top level "and"
+// is encoded with the ampersand form, which is how the parser splits the query into the filter
+// and the special operations. An "and" with value arguments is kept in the function style form,
+// because the parser drops the top level values.
+func appendConjuncts(parts []string, n *RqlNode) []string {
+	if n == nil || !isAndOp(n.Op) || len(n.Args) == 0 || !hasNodeArgs(n) {
+		return append(parts, n.String())
+	}
+	for _, a := range n.Args {
+		parts = appendConjuncts(parts, a.(*RqlNode))
+	}
+	return parts
+}
+
+// hasNodeArgs reports whether all the arguments of the node are nodes.
+func hasNodeArgs(n *RqlNode) bool {
+	for _, a := range n.Args {
+		if _, ok := a.(*RqlNode); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// String encodes the node into its canonical RQL form, using the function style
+// notation and lower case operators. For example: and(eq(foo,42),in(bar,[a,b])).
+func (n *RqlNode) String() string {
+	if n == nil {
+		return ""
+	}
+	if isGroupOp(n.Op) {
+		return encodeGroup(n, true)
+	}
+	var b strings.Builder
+	b.WriteString(encodeString(strings.ToLower(n.Op)))
+	b.WriteByte('(')
+	for i, a := range n.Args {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if isGroupArg(a) {
+			// the field of the group is the preceding argument of the node.
+			b.WriteString(encodeGroup(a.(*RqlNode), false))
+			continue
+		}
+		if i+1 < len(n.Args) && isGroupArg(n.Args[i+1]) {
+			b.WriteString(encodeField(a))
+			continue
+		}
+		b.WriteString(encodeArg(a))
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// encodeGroup encodes the square brackets node. The first argument of the group node
+// is its field, and it is omitted if the group is an argument of another node.
+func encodeGroup(n *RqlNode, withField bool) string {
+	var b strings.Builder
+	values := n.Args
+	if len(values) > 0 {
+		if withField {
+			b.WriteString(encodeField(values[0]))
+			b.WriteByte(',')
+		}
+		values = values[1:]
+	}
+	b.WriteByte('[')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(encodeArg(v))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func encodeSort(s Sort) string {
+	by := encodeString(s.By)
+	if s.Desc {
+		return "-" + by
+	}
+	if by != s.By {
+		// the parser does not unescape arguments that start with a plus sign.
+		return by
+	}
+	return "+" + by
+}
+
+// encodeField encodes the field that precedes a square brackets group. The parser reads it as
+// an identifier, so it is escaped instead of quoted.
+func encodeField(a interface{}) string {
+	if s, ok := a.(string); ok {
+		return encodeString(s)
+	}
+	return encodeArg(a)
+}
+
+// encodeArg encodes a node argument. Numbers and booleans are encoded as plain values, like
+// the values of the query, as the parser converts them back to the field type. Times are
+// encoded with the date prefix, because the layout of their field may differ from RFC3339.
+// Regular expressions are encoded with the re prefix, and strings that look like typed literals
+// with the string prefix, because their plain values are read back differently. Strings with
+// characters that are not valid in an identifier are quoted.
+func encodeArg(a interface{}) string {
+	switch v := a.(type) {
+	case nil:
+		return ""
+	case *RqlNode:
+		return v.String()
+	case string:
+		if encodeString(v) != v {
+			return strconv.Quote(v)
+		}
+		if _, _, ok := splitTypedLiteral(v); ok {
+			return "string:" + v
+		}
+		return v
+	case time.Time:
+		return "date:" + encodeString(formatValue(v))
+	case *regexp.Regexp:
+		return "re:" + encodeString(formatValue(v))
+	}
+	return encodeString(formatValue(a))
+}
+
+// formatValue returns the text of a value, without its type prefix.
+func formatValue(a interface{}) string {
+	switch v := a.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *RqlNode:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// encodeString percent-encodes all the characters that are not valid in an identifier,
+// and the characters that are decoded by the scanner ('%' and '+'). The bytes of invalid
+// UTF-8 sequences are percent-encoded one by one.
+func encodeString(s string) string {
+	i := 0
+	for i < len(s) {
+		ch, size := utf8.DecodeRuneInString(s[i:])
+		if !isSafeRune(ch) {
+			break
+		}
+		i += size
+	}
+	if i == len(s) {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString(s[:i])
+	for i < len(s) {
+		ch, size := utf8.DecodeRuneInString(s[i:])
+		if isSafeRune(ch) {
+			b.WriteString(s[i : i+size])
+		} else {
+			for _, c := range []byte(s[i : i+size]) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		}
+		i += size
+	}
+	return b.String()
+}
+
+func isSafeRune(ch rune) bool {
+	return isIdent(ch) && ch != '%' && ch != '+'
+}
+
+func isAndOp(op string) bool {
+	return strings.ToUpper(op) == "AND"
+}
+
+func isGroupOp(op string) bool {
+	return op == "group"
+}
+
+// isGroupArg reports whether the argument is a square brackets group.
+func isGroupArg(a interface{}) bool {
+	g, ok := a.(*RqlNode)
+	return ok && g != nil && isGroupOp(g.Op)
+}
@@ -0,0 +1,181 @@
+package gorql
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type EncodeTest struct {
+	Name     string      // Name of the test
+	RQL      string      // Input RQL query
+	Model    interface{} // Input Model for query
+	Expected string      // Expected canonical RQL
+}
+
+var encodeTests = []EncodeTest{
+	{
+		Name:     `Func style operators`,
+		RQL:      `and(eq(foo,42),gt(price,10),not(disabled=false))`,
+		Expected: `eq(foo,42)&gt(price,10)&not(eq(disabled,false))`,
+	},
+	{
+		Name:     `Simple equal and ampersand`,
+		RQL:      `foo=42&price=10`,
+		Expected: `eq(foo,42)&eq(price,10)`,
+	},
+	{
+		Name:     `Mixed style`,
+		RQL:      `((eq(foo,42)&gt(price,10))|ge(price,500))&eq(disabled,false)`,
+		Expected: `or(and(eq(foo,42),gt(price,10)),ge(price,500))&eq(disabled,false)`,
+	},
+	{
+		Name:     `Top level or with special operations`,
+		RQL:      `or(eq(foo,1),eq(foo,2))&sort(+price,-name)&select(foo,price)&limit(10,20)`,
+		Expected: `or(eq(foo,1),eq(foo,2))&sort(+price,-name)&select(foo,price)&limit(10,20)`,
+	},
+	{
+		Name:     `Special operations only`,
+		RQL:      `$offset=20&$sort=-price`,
+		Expected: `sort(-price)&offset(20)`,
+	},
+	{
+		Name:     `Square brackets`,
+		RQL:      `in(foo,[a,b,c])`,
+		Expected: `in(foo,[a,b,c])`,
+	},
+	{
+		Name:     `Escaped values`,
+		RQL:      `eq(foo,john%20wick%2C%20%28Jr%29%26co)&eq(bar,a%2Bb)&eq(baz,50%25)`,
+		Expected: `eq(foo,"john wick, (Jr)&co")&eq(bar,"a+b")&eq(baz,"50%")`,
+	},
+	{
+		Name:     `Quoted values`,
+		RQL:      `eq(foo,'it\'s')&eq(bar,"say \"hi\"\n")&eq(baz,"number:5")`,
+		Expected: `eq(foo,"it's")&eq(bar,"say \"hi\"\n")&eq(baz,string:number:5)`,
+	},
+	{
+		Name:     `Empty value`,
+		RQL:      `like(foo,)`,
+		Expected: `like(foo,)`,
+	},
+	{
+		Name: `Validated values`,
+		RQL:  `and(eq(age,42),gt(price,10.5),eq(admin,true),lt(createdAt,2020-01-01T10:00:00%2B02:00))&sort(-age)`,
+		Model: new(struct {
+			Age       int       `rql:"filter,sort"`
+			Price     float64   `rql:"filter"`
+			Admin     bool      `rql:"filter"`
+			CreatedAt time.Time `rql:"filter"`
+		}),
+		Expected: `eq(age,42)&gt(price,10.5)&eq(admin,true)&lt(createdAt,date:2020-01-01T10:00:00%2B02:00)&sort(-age)`,
+	},
+	{
+		Name: `Validated times with a custom layout`,
+		RQL:  `eq(day,2020-01-02)|gt(day,date:2020-01-02T10:30:00Z)`,
+		Model: new(struct {
+			Day time.Time `rql:"filter,layout=2006-01-02"`
+		}),
+		Expected: `or(eq(day,date:2020-01-02T00:00:00Z),gt(day,date:2020-01-02T10:30:00Z))`,
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, test := range encodeTests {
+		test.Run(t)
+	}
+}
+
+func (test EncodeTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Parse error: %v", test.Name, err)
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+	// the canonical form must be stable.
+	root, err = p.Parse(strings.NewReader(test.Expected))
+	if err != nil {
+		t.Fatalf("(%s) Parse error of the encoded query: %v", test.Name, err)
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Round trip mismatch %s vs %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestEncodeModifiedNode(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	root, err := p.Parse(strings.NewReader(`eq(foo,42)&limit(10)`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	root.Node.Args = append(root.Node.Args, &RqlNode{Op: "like", Args: []interface{}{"name", "Smith, John"}})
+	expected := `eq(foo,42)&like(name,"Smith, John")&limit(10)`
+	if s := root.String(); s != expected {
+		t.Fatalf("Expecting RQL %s, got %s", expected, s)
+	}
+	root, err = p.Parse(strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if v := root.Node.Args[1].(*RqlNode).Args[1]; v != "Smith, John" {
+		t.Fatalf("Expecting the value to be unescaped, got %q", v)
+	}
+}
+
+func TestEncodeLayoutRoundTrip(t *testing.T) {
+	p, err := NewParser(&Config{Model: new(struct {
+		Day time.Time `rql:"filter,layout=2006-01-02"`
+	})})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	root, err := p.Parse(strings.NewReader(`eq(day,2020-01-02)`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	again, err := p.Parse(strings.NewReader(root.String()))
+	if err != nil {
+		t.Fatalf("Parse error of the encoded query %s: %v", root, err)
+	}
+	expected := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	if v, ok := again.Node.Args[1].(time.Time); !ok || !v.Equal(expected) {
+		t.Fatalf("Expecting the value %v, got %v", expected, again.Node.Args[1])
+	}
+}
+
+func FuzzEncode(f *testing.F) {
+	for _, test := range encodeTests {
+		f.Add(test.RQL)
+	}
+	f.Fuzz(func(t *testing.T, a string) {
+		p, err := NewParser(nil)
+		if err != nil {
+			t.Fatalf("New parser error :%s", err)
+		}
+		root, err := p.Parse(strings.NewReader(a))
+		if err != nil {
+			return
+		}
+		s := root.String()
+		root, err = p.Parse(strings.NewReader(s))
+		if err != nil {
+			t.Fatalf("Parse error of the encoded query %q: %v", s, err)
+		}
+		if s2 := root.String(); s2 != s {
+			t.Fatalf("Round trip mismatch %q vs %q", s, s2)
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package gorql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError is an error that is attached to a position in the parsed query.
+// It is returned by Parse for syntax errors and for validation errors of
+// filter nodes. The underlying error is accessible with errors.Is and errors.As.
+type ParseError struct {
+	// Query is the original query. It is set by Parse and may be empty
+	// if the error was returned directly by the Scanner.
+	Query string
+	// Pos is the span of the query the error refers to.
+	Pos Pos
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (at offset %d)", e.Err, e.Pos.Start)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Snippet renders the query with the erroneous span underlined with carets.
+// It is suitable for plain-text API error responses. For example:
+//
+//	and(eq(foo,42)
+//	   ^
+func (e *ParseError) Snippet() string {
+	if e.Query == "" {
+		return ""
+	}
+	start, end := clampOffset(e.Query, e.Pos.Start), clampOffset(e.Query, e.Pos.End)
+	if end < start {
+		end = start
+	}
+	width := utf8.RuneCountInString(e.Query[start:end])
+	if width == 0 {
+		width = 1
+	}
+	return e.Query + "\n" + strings.Repeat(" ", utf8.RuneCountInString(e.Query[:start])) + strings.Repeat("^", width)
+}
+
+// setErrorQuery sets the query of all the parse errors in err.
+func setErrorQuery(err error, query string) {
+	switch e := err.(type) {
+	case *ParseError:
+		e.Query = query
+	case ErrorList:
+		for _, err := range e {
+			setErrorQuery(err, query)
+		}
+	}
+}
+
+// clampOffset bounds the given offset to the query length.
+func clampOffset(q string, i int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > len(q) {
+		return len(q)
+	}
+	return i
+}
+
+// errorAt attaches the position of the given token bloc to err. Errors that
+// already carry a position are returned as is.
+func errorAt(tb []TokenString, err error) error {
+	if err == nil {
+		return err
+	}
+	if _, ok := err.(*ParseError); ok {
+		return err
+	}
+	return &ParseError{Pos: blocPos(tb), Err: syntaxError(err)}
+}
+
+// blocPos returns the span that the token bloc covers.
+func blocPos(tb []TokenString) Pos {
+	if len(tb) == 0 {
+		return Pos{}
+	}
+	return Pos{Start: tb[0].pos.Start, End: tb[len(tb)-1].pos.End}
+}
+
+// ErrorCode is a stable, machine-readable identifier of an error kind.
+type ErrorCode string
+
+const (
+	CodeSyntax        ErrorCode = "syntax_error"
+	CodeUnknownField  ErrorCode = "unknown_field"
+	CodeNotFilterable ErrorCode = "not_filterable"
+	CodeNotSortable   ErrorCode = "not_sortable"
+	CodeInvalidValue  ErrorCode = "invalid_value"
+	CodeLimitExceeded ErrorCode = "limit_exceeded"
+	CodeContradiction ErrorCode = "contradiction"
+	CodeTooComplex    ErrorCode = "too_complex"
+)
+
+// CodedError is implemented by all the structured errors returned by the parser.
+type CodedError interface {
+	error
+	Code() ErrorCode
+}
+
+// Code returns the code of the underlying error, or CodeSyntax if it has none.
+func (e *ParseError) Code() ErrorCode {
+	var ce CodedError
+	if errors.As(e.Err, &ce) {
+		return ce.Code()
+	}
+	return CodeSyntax
+}
+
+// SyntaxError is returned when the query is not a well-formed RQL expression.
+type SyntaxError struct {
+	// Msg describes the error.
+	Msg string
+	// Err is an optional sentinel error, such as ErrParenthesisMalformed.
+	Err error
+}
+
+func (e *SyntaxError) Error() string   { return e.Msg }
+func (e *SyntaxError) Unwrap() error   { return e.Err }
+func (e *SyntaxError) Code() ErrorCode { return CodeSyntax }
+
+// UnknownFieldError is returned when a query references a field that does not exist in the model.
+type UnknownFieldError struct {
+	Field string
+	Op    string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q in %s operation", e.Field, e.Op)
+}
+func (e *UnknownFieldError) Code() ErrorCode { return CodeUnknownField }
+
+// NotFilterableError is returned when a filter is applied to a field without the "filter" option.
+type NotFilterableError struct {
+	Field string
+	Op    string
+}
+
+func (e *NotFilterableError) Error() string {
+	return fmt.Sprintf("field name (arg: %s) is not filterable", e.Field)
+}
+func (e *NotFilterableError) Code() ErrorCode { return CodeNotFilterable }
+
+// NotSortableError is returned when sorting by a field without the "sort" option.
+type NotSortableError struct {
+	Field string
+}
+
+func (e *NotSortableError) Error() string   { return fmt.Sprintf("field %s is not sortable", e.Field) }
+func (e *NotSortableError) Code() ErrorCode { return CodeNotSortable }
+
+// InvalidValueError is returned when a value can not be converted to the type of its
+// field, or when a limit or offset value is malformed.
+type InvalidValueError struct {
+	Field string
+	Op    string
+	Value string
+	// Err is the conversion error, if any.
+	Err error
+}
+
+func (e *InvalidValueError) Error() string {
+	target := e.Op
+	if e.Field != "" {
+		target = fmt.Sprintf("field %s", e.Field)
+	}
+	if target == "" {
+		return fmt.Sprintf("invalid value %q: %s", e.Value, e.Err)
+	}
+	if e.Err == nil {
+		return fmt.Sprintf("invalid value %q for %s", e.Value, target)
+	}
+	return fmt.Sprintf("invalid value %q for %s: %s", e.Value, target, e.Err)
+}
+func (e *InvalidValueError) Unwrap() error   { return e.Err }
+func (e *InvalidValueError) Code() ErrorCode { return CodeInvalidValue }
+
+// LimitExceededError is returned when the requested limit is greater than Config.LimitMaxValue.
+type LimitExceededError struct {
+	Limit int
+	Max   int
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("specified limit is more than the max limit %d allowed", e.Max)
+}
+func (e *LimitExceededError) Code() ErrorCode { return CodeLimitExceeded }
+
+// ContradictionError is returned by Normalize when the filter can never be satisfied,
+// for example: eq(a,1)&eq(a,2).
+type ContradictionError struct {
+	// Field is the field of the first contradicting predicates that were found.
+	Field string
+}
+
+func (e *ContradictionError) Error() string {
+	return fmt.Sprintf("filter is never satisfied due to contradicting predicates on field %s", e.Field)
+}
+func (e *ContradictionError) Code() ErrorCode { return CodeContradiction }
+
+// Complexity limits of the Config, reported by ComplexityError.
+const (
+	LimitQueryLength = "length"
+	LimitDepth       = "depth"
+	LimitNodes       = "nodes"
+	LimitArgs        = "args"
+	LimitInValues    = "values"
+	LimitCost        = "cost"
+)
+
+// ComplexityError is returned when a query exceeds one of the complexity limits of the Config,
+// like MaxDepth or MaxNodes, or its cost budget.
+type ComplexityError struct {
+	// Limit is the exceeded limit, e.g. LimitDepth.
+	Limit string
+	// Max is the configured maximum.
+	Max int
+	// Value is the size that exceeds the limit. For the nodes count, it is the count at which
+	// the parser stopped.
+	Value int
+}
+
+func (e *ComplexityError) Error() string {
+	return fmt.Sprintf("query is too complex: %s %d exceeds the maximum of %d", complexityNames[e.Limit], e.Value, e.Max)
+}
+func (e *ComplexityError) Code() ErrorCode { return CodeTooComplex }
+
+var complexityNames = map[string]string{
+	LimitQueryLength: "length",
+	LimitDepth:       "nesting depth",
+	LimitNodes:       "number of operations",
+	LimitArgs:        "number of arguments",
+	LimitInValues:    "number of list values",
+	LimitCost:        "cost",
+}
+
+// ErrorList is returned when Config.AllErrors is set, and holds all the validation
+// errors that were found in the query. Go 1.20 and later versions of errors.Is and
+// errors.As inspect each of its elements.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (l ErrorList) Unwrap() []error {
+	return l
+}
+
+// syntaxError makes sure that the given parsing error is a structured error.
+func syntaxError(err error) error {
+	var ce CodedError
+	if err == nil || errors.As(err, &ce) {
+		return err
+	}
+	se := &SyntaxError{Msg: err.Error()}
+	for _, sentinel := range []error{ErrParenthesisMalformed, ErrUnregonizedBloc, ErrInvalidPlacementSqrBrBloc} {
+		if errors.Is(err, sentinel) {
+			se.Err = sentinel
+		}
+	}
+	return se
+}
@@ -0,0 +1,246 @@
+package gorql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ParseErrorTest struct {
+	Name    string      // Name of the test
+	RQL     string      // Input RQL query
+	Model   interface{} // Input Model for query
+	Pos     Pos         // Expected position of the error
+	Snippet string      // Expected caret-underlined snippet
+}
+
+var parseErrorTests = []ParseErrorTest{
+	{
+		Name:    `Illegal token`,
+		RQL:     `eq(foo,b!r)`,
+		Pos:     Pos{Start: 8, End: 9},
+		Snippet: "eq(foo,b!r)\n        ^",
+	},
+	{
+		Name:    `Missing closing parenthesis`,
+		RQL:     `and(eq(foo,42)`,
+		Pos:     Pos{Start: 3, End: 4},
+		Snippet: "and(eq(foo,42)\n   ^",
+	},
+	{
+		Name: `Field is not filterable`,
+		RQL:  `eq(foo,42)&eq(bar,1)`,
+		Model: new(struct {
+			Foo string `rql:"filter"`
+		}),
+		Pos:     Pos{Start: 11, End: 20},
+		Snippet: "eq(foo,42)&eq(bar,1)\n           ^^^^^^^^^",
+	},
+	{
+		Name: `Multi-byte characters before the error`,
+		RQL:  `eq(foo,%C3%BC)&eq(bar,1)`,
+		Model: new(struct {
+			Foo string `rql:"filter"`
+		}),
+		Pos:     Pos{Start: 15, End: 24},
+		Snippet: "eq(foo,%C3%BC)&eq(bar,1)\n               ^^^^^^^^^",
+	},
+	{
+		Name:    `Unmatched closing parenthesis`,
+		RQL:     `eq(foo,42))&eq(bar,1)`,
+		Pos:     Pos{Start: 10, End: 11},
+		Snippet: "eq(foo,42))&eq(bar,1)\n          ^",
+	},
+	{
+		Name:    `Missing comma between arguments`,
+		RQL:     `and(eq(foo,42)gt(bar,1))`,
+		Pos:     Pos{Start: 14, End: 16},
+		Snippet: "and(eq(foo,42)gt(bar,1))\n              ^^",
+	},
+	{
+		Name:    `Value instead of an operation`,
+		RQL:     `eq(foo,42)&bar`,
+		Pos:     Pos{Start: 11, End: 14},
+		Snippet: "eq(foo,42)&bar\n           ^^^",
+	},
+	{
+		Name:    `Unclosed square brackets`,
+		RQL:     `in(foo,[a,b)`,
+		Pos:     Pos{Start: 7, End: 8},
+		Snippet: "in(foo,[a,b)\n       ^",
+	},
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	for _, test := range parseErrorTests {
+		test.Run(t)
+	}
+}
+
+func (test ParseErrorTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v", test.Name, err)
+	}
+	_, err = p.Parse(strings.NewReader(test.RQL))
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("(%s) Expecting a *ParseError, got: %v", test.Name, err)
+	}
+	if pe.Pos != test.Pos {
+		t.Fatalf("(%s) Expecting position %+v, got %+v", test.Name, test.Pos, pe.Pos)
+	}
+	if s := pe.Snippet(); s != test.Snippet {
+		t.Fatalf("(%s) Expecting snippet:\n%s\ngot:\n%s", test.Name, test.Snippet, s)
+	}
+}
+
+func TestNodePosition(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	root, err := p.Parse(strings.NewReader(`and(eq(foo,42),gt(price,10))`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if root.Node.Pos != (Pos{Start: 0, End: 28}) {
+		t.Fatalf("unexpected root position %+v", root.Node.Pos)
+	}
+	gt := root.Node.Args[1].(*RqlNode)
+	if gt.Pos != (Pos{Start: 15, End: 27}) {
+		t.Fatalf("unexpected child position %+v", gt.Pos)
+	}
+}
+
+type errorsModel struct {
+	Name      string    `rql:"filter,sort"`
+	Age       int       `rql:"filter"`
+	CreatedAt time.Time `rql:"filter,sort"`
+	Rank      int       `rql:"sort"`
+}
+
+type ErrorCodeTest struct {
+	Name   string    // Name of the test
+	RQL    string    // Input RQL query
+	Code   ErrorCode // Expected error code
+	Target error     // Expected error type, used with errors.As
+	Field  string    // Expected field name of the error, if any
+}
+
+var errorCodeTests = []ErrorCodeTest{
+	{
+		Name:   `Syntax error`,
+		RQL:    `and(eq(name,foo)`,
+		Code:   CodeSyntax,
+		Target: &SyntaxError{},
+	},
+	{
+		Name:   `Unknown field`,
+		RQL:    `eq(foo,1)`,
+		Code:   CodeUnknownField,
+		Target: &UnknownFieldError{},
+		Field:  "foo",
+	},
+	{
+		Name:   `Field is not filterable`,
+		RQL:    `eq(rank,1)`,
+		Code:   CodeNotFilterable,
+		Target: &NotFilterableError{},
+		Field:  "rank",
+	},
+	{
+		Name:   `Field is not sortable`,
+		RQL:    `sort(+age)`,
+		Code:   CodeNotSortable,
+		Target: &NotSortableError{},
+		Field:  "age",
+	},
+	{
+		Name:   `Invalid int value`,
+		RQL:    `eq(age,abc)`,
+		Code:   CodeInvalidValue,
+		Target: &InvalidValueError{},
+		Field:  "age",
+	},
+	{
+		Name:   `Bad date layout`,
+		RQL:    `gt(createdAt,yesterday)`,
+		Code:   CodeInvalidValue,
+		Target: &InvalidValueError{},
+		Field:  "createdAt",
+	},
+	{
+		Name:   `Limit exceeded`,
+		RQL:    `limit(1000)`,
+		Code:   CodeLimitExceeded,
+		Target: &LimitExceededError{},
+	},
+}
+
+func TestErrorCodes(t *testing.T) {
+	p, err := NewParser(&Config{Model: errorsModel{}})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	for _, test := range errorCodeTests {
+		_, err := p.Parse(strings.NewReader(test.RQL))
+		var ce CodedError
+		if !errors.As(err, &ce) {
+			t.Fatalf("(%s) Expecting a CodedError, got: %v", test.Name, err)
+		}
+		if ce.Code() != test.Code {
+			t.Fatalf("(%s) Expecting code %s, got %s", test.Name, test.Code, ce.Code())
+		}
+		target := reflect.New(reflect.TypeOf(test.Target))
+		if !errors.As(err, target.Interface()) {
+			t.Fatalf("(%s) Expecting error of type %T, got: %v", test.Name, test.Target, err)
+		}
+		if test.Field != "" {
+			if f := target.Elem().Elem().FieldByName("Field").String(); f != test.Field {
+				t.Fatalf("(%s) Expecting field %q, got %q", test.Name, test.Field, f)
+			}
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	_, err = p.Parse(strings.NewReader(`and(eq(foo,42)`))
+	if !errors.Is(err, ErrParenthesisMalformed) {
+		t.Fatalf("Expecting ErrParenthesisMalformed, got: %v", err)
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	p, err := NewParser(&Config{Model: errorsModel{}, AllErrors: true})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	_, err = p.Parse(strings.NewReader(`and(eq(foo,1),eq(age,abc),eq(rank,x))&sort(+age)&limit(1000)`))
+	var list ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("Expecting an ErrorList, got: %v", err)
+	}
+	var codes []ErrorCode
+	for _, err := range list {
+		codes = append(codes, err.(CodedError).Code())
+	}
+	expected := []ErrorCode{CodeLimitExceeded, CodeNotSortable, CodeUnknownField, CodeInvalidValue, CodeNotFilterable}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Fatalf("Expecting codes %v, got %v", expected, codes)
+	}
+	var pe *ParseError
+	if !errors.As(list[2], &pe) || pe.Query == "" {
+		t.Fatalf("Expecting the query to be set on the collected errors: %v", list[2])
+	}
+}
@@ -0,0 +1,190 @@
+package gorql
+
+import (
+	"strings"
+)
+
+// defaultPhrases are the phrases of the operations in the explanations of the queries, keyed by
+// the lower cased operation. In a phrase, {field} is replaced with the field of the operation,
+// and {value} with its values, separated by commas.
+var defaultPhrases = map[string]string{
+	"eq":    "{field} is {value}",
+	"ne":    "{field} is not {value}",
+	"gt":    "{field} is greater than {value}",
+	"ge":    "{field} is greater than or equal to {value}",
+	"lt":    "{field} is less than {value}",
+	"le":    "{field} is less than or equal to {value}",
+	"like":  "{field} is like {value}",
+	"match": "{field} is like {value}, ignoring case",
+	"in":    "{field} is one of {value}",
+}
+
+// DefaultPhrases returns a copy of the phrases of the operations in the explanations of the
+// queries, keyed by the lower cased operation. In a phrase, {field} is replaced with the field
+// of the operation, and {value} with its values, separated by commas. Config.Phrases overrides
+// them, and describes the custom operations of the drivers, e.g. {"near": "{field} is near {value}"}.
+func DefaultPhrases() map[string]string {
+	return cloneMap(defaultPhrases)
+}
+
+// Explain renders the query as plain English, with the DefaultPhrases and the field names of
+// the query. For example:
+//
+//	gt(price,10)&in(status,[a,b])&sort(-price)&limit(20,40)
+//	=> price is greater than 10 and status is one of a, b; sorted by price descending; first 20 results after skipping 40
+//
+// The operations without a phrase are rendered in RQL form.
+func Explain(root *RqlRootNode) string {
+	return explainer{phrases: defaultPhrases}.explain(root)
+}
+
+// Explain renders the query as plain English, like the Explain function. The phrases of
+// Config.Phrases override the DefaultPhrases, and the fields are named after the fields of the
+// model, even if they are replaced by the "replacewith" option.
+func (p *Parser) Explain(root *RqlRootNode) string {
+	e := explainer{phrases: defaultPhrases, name: func(name string) string {
+		if f, ok := p.lookupField(name); ok {
+			return f.Name
+		}
+		return name
+	}}
+	if p.c != nil && len(p.c.Phrases) > 0 {
+		e.phrases = make(map[string]string, len(defaultPhrases)+len(p.c.Phrases))
+		for op, phrase := range defaultPhrases {
+			e.phrases[op] = phrase
+		}
+		for op, phrase := range p.c.Phrases {
+			e.phrases[strings.ToLower(op)] = phrase
+		}
+	}
+	return e.explain(root)
+}
+
+// explainer renders the queries with the phrases of the operations, and the names of the fields.
+type explainer struct {
+	phrases map[string]string
+	name    func(string) string
+}
+
+func (e explainer) fieldName(name string) string {
+	if e.name == nil {
+		return name
+	}
+	return e.name(name)
+}
+
+func (e explainer) explain(root *RqlRootNode) string {
+	if root == nil {
+		return "all results"
+	}
+	var parts []string
+	if root.Node != nil {
+		parts = append(parts, e.node(root.Node, ""))
+	}
+	if len(root.sorts) > 0 {
+		sorts := make([]string, len(root.sorts))
+		for i, s := range root.sorts {
+			sorts[i] = e.fieldName(s.By) + " ascending"
+			if s.Desc {
+				sorts[i] = e.fieldName(s.By) + " descending"
+			}
+		}
+		parts = append(parts, "sorted by "+strings.Join(sorts, ", then "))
+	}
+	if len(root.selects) > 0 {
+		selects := make([]string, len(root.selects))
+		for i, s := range root.selects {
+			selects[i] = e.fieldName(s)
+		}
+		parts = append(parts, "showing "+strings.Join(selects, ", "))
+	}
+	if paging := explainPaging(root.limit, root.offset); paging != "" {
+		parts = append(parts, paging)
+	}
+	if len(parts) == 0 {
+		return "all results"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// explainPaging returns the explanation of the limit and the offset.
+func explainPaging(limit, offset string) string {
+	var s string
+	switch limit {
+	case "":
+	case "1":
+		s = "first result"
+	default:
+		s = "first " + limit + " results"
+	}
+	switch {
+	case offset == "" || offset == "0":
+		return s
+	case s == "":
+		return "skipping the first " + offset + " results"
+	}
+	return s + " after skipping " + offset
+}
+
+// node returns the explanation of the node. The and/or operations are enclosed in parentheses
+// if they are the arguments of another logical operation.
+func (e explainer) node(n *RqlNode, parent string) string {
+	n = unwrapNode(n)
+	op := strings.ToLower(n.Op)
+	switch {
+	case (op == "and" || op == "or") && len(n.Args) > 0:
+		args := make([]string, len(n.Args))
+		for i, a := range n.Args {
+			args[i] = e.arg(a, op)
+		}
+		s := strings.Join(args, " "+op+" ")
+		if parent != "" && parent != op {
+			s = "(" + s + ")"
+		}
+		return s
+	case op == "not" && len(n.Args) > 0:
+		args := make([]string, len(n.Args))
+		for i, a := range n.Args {
+			args[i] = e.arg(a, op)
+		}
+		return "not " + strings.Join(args, " or ")
+	}
+	phrase, ok := e.phrases[op]
+	if !ok || len(n.Args) == 0 {
+		return n.String()
+	}
+	field, _ := n.Args[0].(string)
+	var values []string
+	for _, a := range n.Args[1:] {
+		values = append(values, e.value(a))
+	}
+	return strings.NewReplacer("{field}", e.fieldName(field), "{value}", strings.Join(values, ", ")).Replace(phrase)
+}
+
+// arg returns the explanation of an argument of a logical operation.
+func (e explainer) arg(a interface{}, parent string) string {
+	if n, ok := a.(*RqlNode); ok && n != nil {
+		if parent == "not" {
+			// not(eq(a,1)) is "not (a is 1)", to be read as a negation of the whole predicate.
+			return "(" + e.node(n, "") + ")"
+		}
+		return e.node(n, parent)
+	}
+	return formatValue(a)
+}
+
+// value returns the explanation of a value, where the lists of values are separated by commas.
+func (e explainer) value(a interface{}) string {
+	n, ok := a.(*RqlNode)
+	if !ok || n == nil {
+		return formatValue(a)
+	}
+	if !isGroupOp(n.Op) {
+		return e.node(n, "")
+	}
+	values := make([]string, 0, len(n.Args))
+	for _, v := range n.Args[1:] {
+		values = append(values, formatValue(v))
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,110 @@
+package gorql
+
+import (
+	"strings"
+	"testing"
+)
+
+type ExplainTest struct {
+	Name     string  // Name of the test
+	RQL      string  // Input RQL query
+	Config   *Config // Input configuration of the parser, if the query is validated
+	Expected string  // Expected explanation
+}
+
+type explainModel struct {
+	Price     float64 `rql:"filter,sort"`
+	Status    string  `rql:"filter,column=status"`
+	Name      string  `rql:"filter,sort"`
+	CreatedAt string  `rql:"filter,sort,column=createdAt,replacewith=created_at"`
+}
+
+var explainTests = []ExplainTest{
+	{
+		Name:     `Filter, sort and paging`,
+		RQL:      `gt(price,10)&in(status,[a,b])&sort(-price)&limit(20,40)`,
+		Expected: `price is greater than 10 and status is one of a, b; sorted by price descending; first 20 results after skipping 40`,
+	},
+	{
+		Name:     `Nested logical operations`,
+		RQL:      `or(eq(a,1),and(ne(b,2),le(c,3)))&not(like(d,x*))`,
+		Expected: `(a is 1 or (b is not 2 and c is less than or equal to 3)) and not (d is like x*)`,
+	},
+	{
+		Name:     `Unknown operations`,
+		RQL:      `near(location,"1,2")&ge(a,1)`,
+		Expected: `near(location,"1,2") and a is greater than or equal to 1`,
+	},
+	{
+		Name:     `Sort, select and offset`,
+		RQL:      `sort(+name,-price)&select(name,price)&offset(10)`,
+		Expected: `sorted by name ascending, then price descending; showing name, price; skipping the first 10 results`,
+	},
+	{
+		Name:     `Single result`,
+		RQL:      `eq(a,1)&limit(1)`,
+		Expected: `a is 1; first result`,
+	},
+	{
+		Name:     `Empty query`,
+		RQL:      ``,
+		Expected: `all results`,
+	},
+	{
+		Name:     `Model field names`,
+		RQL:      `eq(createdAt,2020)&sort(-createdAt)&select(createdAt)`,
+		Config:   &Config{Model: explainModel{}},
+		Expected: `createdAt is 2020; sorted by createdAt descending; showing createdAt`,
+	},
+	{
+		Name:     `Custom phrases`,
+		RQL:      `near(price,10)&eq(name,foo)&match(status,x*)`,
+		Config:   &Config{Model: explainModel{}, Phrases: map[string]string{"NEAR": "{field} is close to {value}", "eq": "{field} equals {value}"}},
+		Expected: `price is close to 10 and name equals foo and status is like x*, ignoring case`,
+	},
+}
+
+func TestExplain(t *testing.T) {
+	for _, test := range explainTests {
+		test.Run(t)
+	}
+}
+
+func (test ExplainTest) Run(t *testing.T) {
+	if test.Config == nil {
+		if s := Explain(mustParse(t, test.RQL)); s != test.Expected {
+			t.Fatalf("(%s) Expecting explanation:\n%s\ngot:\n%s", test.Name, test.Expected, s)
+		}
+		return
+	}
+	p, err := NewParser(test.Config)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v", test.Name, err)
+	}
+	if test.Config.Phrases != nil {
+		// the custom operations are not validated by the parser.
+		root := mustParse(t, test.RQL)
+		if s := p.Explain(root); s != test.Expected {
+			t.Fatalf("(%s) Expecting explanation:\n%s\ngot:\n%s", test.Name, test.Expected, s)
+		}
+		return
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Parse error :%v", test.Name, err)
+	}
+	if s := p.Explain(root); s != test.Expected {
+		t.Fatalf("(%s) Expecting explanation:\n%s\ngot:\n%s", test.Name, test.Expected, s)
+	}
+}
+
+func TestDefaultPhrasesCopy(t *testing.T) {
+	phrases := DefaultPhrases()
+	if phrases["eq"] != "{field} is {value}" {
+		t.Fatalf("Unexpected phrase of eq: %q", phrases["eq"])
+	}
+	phrases["eq"] = "{field} equals {value}"
+	if s := Explain(mustParse(t, `eq(a,1)`)); s != "a is 1" {
+		t.Fatalf("Expecting the default phrases to be unchanged, got: %s", s)
+	}
+}
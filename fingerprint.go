@@ -0,0 +1,129 @@
+package gorql
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// placeholder replaces the values in the template of a fingerprint.
+const placeholder = "?"
+
+// Fingerprint returns the shape of the query, to group the queries that differ only by their
+// values, like pg_stat_statements does. The template is the query in RQL form, where:
+//
+//   - the values are replaced with "?", and the lists of values with "[?]", whatever their length.
+//   - the nested and/or operations are flattened, and their arguments are sorted.
+//   - the operations are lower cased, and the top level conjunction uses the '&' form.
+//   - the sort and select fields are kept, and the limit and offset are replaced with "?".
+//
+// For example, the template of or(eq(b,1),eq(a,2))&in(c,[x,y])&sort(-a)&limit(10,20) is:
+//
+//	in(c,[?])&or(eq(a,?),eq(b,?))&sort(-a)&limit(?,?)
+//
+// The hash is the 64-bit FNV-1a hash of the template, which is stable across processes and versions
+// of Go. The first argument of an operation is taken as a field if it is a string, like in the drivers.
+func Fingerprint(root *RqlRootNode) (hash uint64, template string) {
+	var parts []string
+	if root != nil && root.Node != nil {
+		if n := unwrapNode(root.Node); strings.EqualFold(n.Op, "and") && hasNodeArgs(n) {
+			parts = templateArgs("and", n, false)
+		} else {
+			parts = append(parts, templateOf(n, false))
+		}
+	}
+	if root != nil {
+		if len(root.sorts) > 0 {
+			sorts := make([]string, len(root.sorts))
+			for i, s := range root.sorts {
+				sorts[i] = encodeSort(s)
+			}
+			parts = append(parts, SortOp+"("+strings.Join(sorts, ",")+")")
+		}
+		if len(root.selects) > 0 {
+			selects := make([]string, len(root.selects))
+			for i, s := range root.selects {
+				selects[i] = encodeString(s)
+			}
+			parts = append(parts, SelectOp+"("+strings.Join(selects, ",")+")")
+		}
+		switch {
+		case root.limit != "" && root.offset != "":
+			parts = append(parts, LimitOp+"(?,?)")
+		case root.limit != "":
+			parts = append(parts, LimitOp+"(?)")
+		case root.offset != "":
+			parts = append(parts, OffsetOp+"(?)")
+		}
+	}
+	template = strings.Join(parts, "&")
+	h := fnv.New64a()
+	h.Write([]byte(template))
+	return h.Sum64(), template
+}
+
+// templateOf returns the template of the node, with the values replaced with placeholders
+// unless keepValues is true. The templates of equivalent nodes, whose and/or arguments are
+// in a different order, are equal.
+func templateOf(n *RqlNode, keepValues bool) string {
+	n = unwrapNode(n)
+	op := strings.ToLower(n.Op)
+	if (op == "and" || op == "or") && len(n.Args) > 0 && hasNodeArgs(n) {
+		return op + "(" + strings.Join(templateArgs(op, n, keepValues), ",") + ")"
+	}
+	if isGroupOp(op) && len(n.Args) > 0 {
+		// a list of values in the field,[values...] form.
+		return templateArg(n.Args[0], true, keepValues) + "," + templateArg(n, false, keepValues)
+	}
+	args := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		args[i] = templateArg(a, i == 0, keepValues)
+	}
+	return op + "(" + strings.Join(args, ",") + ")"
+}
+
+// templateArgs returns the sorted templates of the arguments of an and/or node, where the
+// arguments of the nested nodes of the same operation are inlined.
+func templateArgs(op string, n *RqlNode, keepValues bool) []string {
+	var args []string
+	for _, a := range n.Args {
+		c := unwrapNode(a.(*RqlNode))
+		if strings.EqualFold(c.Op, op) && len(c.Args) > 0 && hasNodeArgs(c) {
+			args = append(args, templateArgs(op, c, keepValues)...)
+		} else {
+			args = append(args, templateOf(c, keepValues))
+		}
+	}
+	sort.Strings(args)
+	return args
+}
+
+// templateArg returns the template of an argument. The field is kept if the argument
+// is the first one.
+func templateArg(a interface{}, first, keepValues bool) string {
+	switch v := a.(type) {
+	case *RqlNode:
+		if v == nil {
+			return ""
+		}
+		if !isGroupOp(v.Op) {
+			return templateOf(v, keepValues)
+		}
+		if !keepValues {
+			return "[" + placeholder + "]"
+		}
+		values := make([]string, 0, len(v.Args))
+		for _, x := range v.Args[1:] {
+			values = append(values, encodeArg(x))
+		}
+		return "[" + strings.Join(values, ",") + "]"
+	case string:
+		if first {
+			return encodeString(v)
+		}
+	}
+	if keepValues {
+		return encodeArg(a)
+	}
+	return placeholder
+}
@@ -0,0 +1,86 @@
+package gorql
+
+import (
+	"testing"
+)
+
+type FingerprintTest struct {
+	Name     string   // Name of the test
+	RQL      []string // Input RQL queries of the same shape
+	Expected string   // Expected template
+}
+
+var fingerprintTests = []FingerprintTest{
+	{
+		Name:     `Values are replaced`,
+		RQL:      []string{`eq(name,foo)&gt(price,10)`, `eq(name,"bar baz")&gt(price,2.5)`},
+		Expected: `eq(name,?)&gt(price,?)`,
+	},
+	{
+		Name:     `Commutative operations are sorted`,
+		RQL:      []string{`or(eq(b,1),eq(a,2))&eq(c,3)`, `eq(c,4)&or(eq(a,5),eq(b,6))`, `and(eq(c,1),eq(a,2)|eq(b,3))`},
+		Expected: `eq(c,?)&or(eq(a,?),eq(b,?))`,
+	},
+	{
+		Name:     `Nested operations are flattened`,
+		RQL:      []string{`and(eq(a,1),and(eq(b,2),eq(c,3)))`, `eq(c,1)&eq(b,2)&eq(a,3)`},
+		Expected: `eq(a,?)&eq(b,?)&eq(c,?)`,
+	},
+	{
+		Name:     `Lists of values`,
+		RQL:      []string{`in(status,[a,b,c])&not(in(tag,[x]))`, `not(in(tag,[y,z]))&in(status,[d])`},
+		Expected: `in(status,[?])&not(in(tag,[?]))`,
+	},
+	{
+		Name:     `Sort, select and paging`,
+		RQL:      []string{`eq(a,1)&sort(-a,+b)&select(a,b)&limit(10,20)`, `select(a,b)&limit(5)&offset(0)&sort(-a,b)&eq(a,2)`},
+		Expected: `eq(a,?)&sort(-a,+b)&select(a,b)&limit(?,?)`,
+	},
+	{
+		Name:     `Paging only`,
+		RQL:      []string{`offset(10)`, `$offset=20`},
+		Expected: `offset(?)`,
+	},
+	{
+		Name:     `Empty query`,
+		RQL:      []string{``},
+		Expected: ``,
+	},
+}
+
+func TestFingerprint(t *testing.T) {
+	for _, test := range fingerprintTests {
+		test.Run(t)
+	}
+}
+
+func (test FingerprintTest) Run(t *testing.T) {
+	var first uint64
+	for i, q := range test.RQL {
+		hash, template := Fingerprint(mustParse(t, q))
+		if template != test.Expected {
+			t.Fatalf("(%s) Expecting template of %s to be %s, got %s", test.Name, q, test.Expected, template)
+		}
+		if i == 0 {
+			first = hash
+		} else if hash != first {
+			t.Fatalf("(%s) Expecting the hash of %s to be %x, got %x", test.Name, q, first, hash)
+		}
+	}
+}
+
+func TestFingerprintDiffers(t *testing.T) {
+	shapes := []string{`eq(a,1)`, `eq(b,1)`, `ne(a,1)`, `eq(a,1)&sort(+a)`, `eq(a,1)&sort(-a)`, `eq(a,1)&limit(1)`, `or(eq(a,1),eq(b,1))`}
+	seen := make(map[uint64]string)
+	for _, q := range shapes {
+		hash, _ := Fingerprint(mustParse(t, q))
+		if other, ok := seen[hash]; ok {
+			t.Fatalf("Expecting %s and %s to have different fingerprints", q, other)
+		}
+		seen[hash] = q
+	}
+	// the hash is stable across processes.
+	if hash, _ := Fingerprint(mustParse(t, `eq(a,1)`)); hash != 0x33074940b5989488 {
+		t.Fatalf("Unexpected hash %#x", hash)
+	}
+}
@@ -0,0 +1,289 @@
+package gorql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// fiqlComparisons maps the FIQL/RSQL comparison operators to the RQL operations.
+// Other operators of the form =name= are mapped to the operation "name", e.g. =like=.
+var fiqlComparisons = map[string]string{
+	"==":   "eq",
+	"!=":   "ne",
+	"<":    "lt",
+	"<=":   "le",
+	">":    "gt",
+	">=":   "ge",
+	"=lt=": "lt",
+	"=le=": "le",
+	"=gt=": "gt",
+	"=ge=": "ge",
+}
+
+// ParseFIQL constructs an AST from a FIQL/RSQL query. For example:
+//
+//	name==foo;age=gt=30,status=in=(a,b)
+//
+// The semicolon is the logical "and" and the comma is the logical "or", which has a lower precedence.
+// The comparisons ==, !=, <, <=, >, >= and their =lt=, =le=, =gt=, =ge= forms are mapped to the RQL
+// operations, =in= to in(field,[values]), =out= to not(in(field,[values])) and any other =name= operator
+// to the "name" operation, e.g. =like=. Values may be percent-encoded or quoted, and typed literals
+// are supported like in Parse.
+//
+// The result is validated with the configuration of the parser, like the result of Parse. FIQL has no
+// special operations, so the sort, select, limit and offset of the root node are empty.
+func (p *Parser) ParseFIQL(r io.Reader) (root *RqlRootNode, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	query := string(b)
+	defer func() {
+		setErrorQuery(err, query)
+	}()
+	if err = p.checkLength(query); err != nil {
+		return nil, err
+	}
+	fp := &fiqlParser{s: query, p: p}
+	root = &RqlRootNode{}
+	if fp.skipSpace(); fp.pos < len(fp.s) {
+		if root.Node, err = fp.parseOr(); err != nil {
+			return nil, err
+		}
+		if fp.skipSpace(); fp.pos < len(fp.s) {
+			return nil, fp.errorf(fp.pos, fp.pos+1, "unexpected character : %c", fp.s[fp.pos])
+		}
+	}
+	if err = p.checkComplexity(root.Node); err != nil {
+		return nil, err
+	}
+	if err = p.checkCost(context.Background(), root); err != nil {
+		return nil, err
+	}
+	if err = p.validate(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// fiqlParser is a recursive descent parser of FIQL/RSQL queries.
+type fiqlParser struct {
+	s   string
+	pos int
+	// p is the parser that holds the complexity limits, and depth is the nesting of parentheses.
+	p     *Parser
+	depth int
+}
+
+// parseOr parses the comma separated disjunction of conjunctions.
+func (fp *fiqlParser) parseOr() (*RqlNode, error) {
+	return fp.parseList(',', "or", fp.parseAnd)
+}
+
+// parseAnd parses the semicolon separated conjunction of constraints.
+func (fp *fiqlParser) parseAnd() (*RqlNode, error) {
+	return fp.parseList(';', "and", fp.parseConstraint)
+}
+
+// parseList parses the operands separated by sep, and joins them with the op operation
+// if there are more than one.
+func (fp *fiqlParser) parseList(sep byte, op string, operand func() (*RqlNode, error)) (*RqlNode, error) {
+	start := fp.pos
+	n, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	args := []interface{}{n}
+	for fp.skipSpace(); fp.pos < len(fp.s) && fp.s[fp.pos] == sep; fp.skipSpace() {
+		fp.pos++
+		if n, err = operand(); err != nil {
+			return nil, err
+		}
+		args = append(args, n)
+	}
+	if len(args) == 1 {
+		return n, nil
+	}
+	return &RqlNode{Op: op, Args: args, Pos: Pos{Start: start, End: n.Pos.End}}, nil
+}
+
+// parseConstraint parses a comparison, or a parenthesized query.
+func (fp *fiqlParser) parseConstraint() (*RqlNode, error) {
+	fp.skipSpace()
+	start := fp.pos
+	if fp.pos < len(fp.s) && fp.s[fp.pos] == '(' {
+		if fp.depth++; fp.p.exceeds(LimitDepth, fp.depth) {
+			return nil, fp.p.complexityError(LimitDepth, fp.depth, Pos{Start: start, End: start + 1})
+		}
+		fp.pos++
+		n, err := fp.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if fp.skipSpace(); fp.pos >= len(fp.s) || fp.s[fp.pos] != ')' {
+			return nil, fp.parenthesisError(start)
+		}
+		fp.pos++
+		fp.depth--
+		return n, nil
+	}
+	field := fp.scanUnreserved()
+	if field == "" {
+		return nil, fp.errorf(start, start+1, "expecting a selector")
+	}
+	field, err := url.PathUnescape(field)
+	if err != nil {
+		return nil, fp.errorf(start, fp.pos, "invalid selector : %s", fp.s[start:fp.pos])
+	}
+	opStart := fp.pos
+	op, ok := fp.scanComparison()
+	if !ok {
+		return nil, fp.errorf(opStart, opStart+1, "expecting a comparison operator after %s", field)
+	}
+	opEnd := fp.pos
+	values, err := fp.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+	pos := Pos{Start: start, End: fp.pos}
+	switch op {
+	case "in", "out":
+		group := &RqlNode{Op: "group", Args: append([]interface{}{field}, values...), Pos: pos}
+		n := &RqlNode{Op: "in", Args: []interface{}{field, group}, Pos: pos}
+		if op == "out" {
+			n = &RqlNode{Op: "not", Args: []interface{}{n}, Pos: pos}
+		}
+		return n, nil
+	}
+	if len(values) > 1 {
+		if _, ok := fiqlComparisons[fp.s[opStart:opEnd]]; ok {
+			return nil, fp.errorf(opStart, opEnd, "operator %s expects a single argument", fp.s[opStart:opEnd])
+		}
+	}
+	return &RqlNode{Op: op, Args: append([]interface{}{field}, values...), Pos: pos}, nil
+}
+
+// scanComparison scans a comparison operator, and returns its RQL operation.
+func (fp *fiqlParser) scanComparison() (string, bool) {
+	rest := fp.s[fp.pos:]
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, op) {
+			fp.pos += len(op)
+			return fiqlComparisons[op], true
+		}
+	}
+	if len(rest) < 3 || rest[0] != '=' {
+		return "", false
+	}
+	i := 1
+	for i < len(rest) && rest[i] < utf8.RuneSelf && IsLetter(rune(rest[i])) {
+		i++
+	}
+	if i == 1 || i == len(rest) || rest[i] != '=' {
+		return "", false
+	}
+	op := rest[:i+1]
+	fp.pos += len(op)
+	if rql, ok := fiqlComparisons[op]; ok {
+		return rql, true
+	}
+	return strings.ToLower(op[1:i]), true
+}
+
+// parseArguments parses a single value, or a parenthesized list of values.
+func (fp *fiqlParser) parseArguments() ([]interface{}, error) {
+	if fp.pos >= len(fp.s) || fp.s[fp.pos] != '(' {
+		v, err := fp.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{v}, nil
+	}
+	start := fp.pos
+	fp.pos++
+	var values []interface{}
+	for {
+		fp.skipSpace()
+		v, err := fp.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		fp.skipSpace()
+		if fp.pos >= len(fp.s) {
+			return nil, fp.parenthesisError(start)
+		}
+		switch fp.s[fp.pos] {
+		case ',':
+			fp.pos++
+		case ')':
+			fp.pos++
+			return values, nil
+		default:
+			return nil, fp.errorf(fp.pos, fp.pos+1, "unexpected character : %c", fp.s[fp.pos])
+		}
+	}
+}
+
+// parseValue parses a quoted or unreserved value, and converts typed literals to their type.
+func (fp *fiqlParser) parseValue() (interface{}, error) {
+	start := fp.pos
+	t := TokenString{t: Ident}
+	if fp.pos < len(fp.s) && isQuote(rune(fp.s[fp.pos])) {
+		quote := fp.s[fp.pos]
+		for fp.pos++; fp.pos < len(fp.s) && fp.s[fp.pos] != quote; fp.pos++ {
+			if fp.s[fp.pos] == '\\' {
+				fp.pos++
+			}
+		}
+		if fp.pos >= len(fp.s) {
+			return nil, fp.errorf(start, len(fp.s), "unterminated string : %s", fp.s[start:])
+		}
+		fp.pos++
+		v, err := unquote(fp.s[start+1:fp.pos-1], quote)
+		if err != nil {
+			return nil, fp.errorf(start, fp.pos, "invalid escape sequence in string : %s", fp.s[start:fp.pos])
+		}
+		t.t, t.s = String, v
+	} else {
+		raw := fp.scanUnreserved()
+		if raw == "" {
+			return nil, fp.errorf(start, start+1, "expecting a value")
+		}
+		if v, err := url.PathUnescape(raw); err == nil {
+			raw = v
+		}
+		t.s = raw
+	}
+	t.pos = Pos{Start: start, End: fp.pos}
+	return tokenValue(t)
+}
+
+// scanUnreserved scans the characters that are allowed in selectors and unquoted values.
+func (fp *fiqlParser) scanUnreserved() string {
+	start := fp.pos
+	for fp.pos < len(fp.s) && !strings.ContainsRune(`"'();,=!~<> `, rune(fp.s[fp.pos])) {
+		fp.pos++
+	}
+	return fp.s[start:fp.pos]
+}
+
+func (fp *fiqlParser) skipSpace() {
+	for fp.pos < len(fp.s) && fp.s[fp.pos] == ' ' {
+		fp.pos++
+	}
+}
+
+// errorf returns a syntax error at the given span of the query.
+func (fp *fiqlParser) errorf(start, end int, format string, args ...interface{}) error {
+	return &ParseError{Pos: Pos{Start: start, End: end}, Err: &SyntaxError{Msg: fmt.Sprintf(format, args...)}}
+}
+
+// parenthesisError returns the error of a parenthesis that is not closed.
+func (fp *fiqlParser) parenthesisError(start int) error {
+	return &ParseError{Pos: Pos{Start: start, End: start + 1}, Err: &SyntaxError{Msg: ErrParenthesisMalformed.Error(), Err: ErrParenthesisMalformed}}
+}
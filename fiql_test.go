@@ -0,0 +1,189 @@
+package gorql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func FuzzParseFIQL(f *testing.F) {
+	f.Add(`name==foo;age=gt=30,status=in=(a,b)`)
+	f.Fuzz(func(t *testing.T, a string) {
+		p, err := NewParser(nil)
+		if err != nil {
+			t.Fatalf("New parser error :%s", err)
+		}
+		_, _ = p.ParseFIQL(strings.NewReader(a))
+	})
+}
+
+type FIQLTest struct {
+	Name     string      // Name of the test
+	FIQL     string      // Input FIQL/RSQL query
+	Model    interface{} // Input Model for query
+	Expected string      // Expected canonical RQL
+	WantErr  bool        // Expected syntax error
+}
+
+var fiqlTests = []FIQLTest{
+	{
+		Name:     `Precedence of and over or`,
+		FIQL:     `name==foo;age=gt=30,status=in=(a,b)`,
+		Expected: `or(and(eq(name,foo),gt(age,30)),in(status,[a,b]))`,
+	},
+	{
+		Name:     `Top level and`,
+		FIQL:     `name!=foo;age<30;age>=10`,
+		Expected: `ne(name,foo)&lt(age,30)&ge(age,10)`,
+	},
+	{
+		Name:     `Parentheses`,
+		FIQL:     `name==foo;(age=lt=10,age=ge=60)`,
+		Expected: `eq(name,foo)&or(lt(age,10),ge(age,60))`,
+	},
+	{
+		Name:     `Out operator`,
+		FIQL:     `status=out=(a,b)`,
+		Expected: `not(in(status,[a,b]))`,
+	},
+	{
+		Name:     `Custom operator`,
+		FIQL:     `name=like=jo*`,
+		Expected: `like(name,jo*)`,
+	},
+	{
+		Name:     `Quoted and escaped values`,
+		FIQL:     `name=="Smith, John (Jr)";city=='it\'s';tag==a%20b`,
+		Expected: `eq(name,"Smith, John (Jr)")&eq(city,"it's")&eq(tag,"a b")`,
+	},
+	{
+		Name:     `Typed literals`,
+		FIQL:     `age=gt=number:30;code=="number:5"`,
+		Expected: `gt(age,30)&eq(code,string:number:5)`,
+	},
+	{
+		Name:     `Spaces around operators`,
+		FIQL:     ` name==foo ; ( age==1 , age==2 ) `,
+		Expected: `eq(name,foo)&or(eq(age,1),eq(age,2))`,
+	},
+	{
+		Name:     `Empty query`,
+		FIQL:     ``,
+		Expected: ``,
+	},
+	{
+		Name: `Model validation`,
+		FIQL: `age=gt=30;name==foo`,
+		Model: new(struct {
+			Age  int    `rql:"filter"`
+			Name string `rql:"filter"`
+		}),
+		Expected: `gt(age,30)&eq(name,foo)`,
+	},
+	{
+		Name:    `Missing operator`,
+		FIQL:    `name=foo`,
+		WantErr: true,
+	},
+	{
+		Name:    `Missing value`,
+		FIQL:    `name==;age==1`,
+		WantErr: true,
+	},
+	{
+		Name:    `Unclosed parenthesis`,
+		FIQL:    `(name==foo;age==1`,
+		WantErr: true,
+	},
+	{
+		Name:    `Multiple values for a comparison`,
+		FIQL:    `age=gt=(1,2)`,
+		WantErr: true,
+	},
+	{
+		Name:    `Unterminated string`,
+		FIQL:    `name=="foo`,
+		WantErr: true,
+	},
+	{
+		Name:    `Trailing characters`,
+		FIQL:    `name==foo)`,
+		WantErr: true,
+	},
+}
+
+func TestParseFIQL(t *testing.T) {
+	for _, test := range fiqlTests {
+		test.Run(t)
+	}
+}
+
+func (test FIQLTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.ParseFIQL(strings.NewReader(test.FIQL))
+	if test.WantErr {
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Fatalf("(%s) Expected a syntax error, got: %v", test.Name, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error: %v", test.Name, err)
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestParseFIQLMatchesParse(t *testing.T) {
+	p, err := NewParser(&Config{Model: new(struct {
+		Age    int    `rql:"filter"`
+		Status string `rql:"filter"`
+	})})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	fiql, err := p.ParseFIQL(strings.NewReader(`age=ge=18;status=in=(a,b)`))
+	if err != nil {
+		t.Fatalf("ParseFIQL error: %v", err)
+	}
+	rql, err := p.Parse(strings.NewReader(`and(ge(age,18),in(status,[a,b]))`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	clearPos(fiql.Node)
+	clearPos(rql.Node)
+	if !reflect.DeepEqual(fiql.Node, rql.Node) {
+		t.Fatalf("Expecting the tree %s, got %s", rql.Node, fiql.Node)
+	}
+}
+
+func TestParseFIQLErrors(t *testing.T) {
+	p, err := NewParser(&Config{Model: new(struct {
+		Age int `rql:"filter"`
+	})})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	_, err = p.ParseFIQL(strings.NewReader(`age==1;name==foo`))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code() != CodeUnknownField {
+		t.Fatalf("Expecting an unknown field error, got: %v", err)
+	}
+	if pe.Pos != (Pos{Start: 7, End: 16}) {
+		t.Fatalf("Unexpected position of the error: %+v", pe.Pos)
+	}
+	_, err = p.ParseFIQL(strings.NewReader(`age==1;age=gt=(1,2)`))
+	if !errors.As(err, &pe) || pe.Snippet() != "age==1;age=gt=(1,2)\n          ^^^^" {
+		t.Fatalf("Unexpected error snippet: %v", err)
+	}
+}
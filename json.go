@@ -0,0 +1,383 @@
+package gorql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonComparisons maps the field operators of a JSON filter document to the RQL operations.
+var jsonComparisons = map[string]string{
+	"$eq":    "eq",
+	"$ne":    "ne",
+	"$gt":    "gt",
+	"$gte":   "ge",
+	"$lt":    "lt",
+	"$lte":   "le",
+	"$like":  "like",
+	"$match": "match",
+}
+
+// jsonMember is a member of a JSON object, with the span from its key to the end of its value.
+type jsonMember struct {
+	Key   string
+	Value interface{}
+	Pos   Pos
+}
+
+// jsonObject is a JSON object that keeps the order of its members.
+type jsonObject []jsonMember
+
+// ParseJSON constructs an AST from a Mongo-style JSON filter document. For example:
+//
+//	{"$and":[{"price":{"$gt":10}},{"tags":{"$in":["a","b"]}}],"$sort":{"price":-1},"$limit":10}
+//
+// A field with a value is an equality, and a field with an object of operators is the conjunction
+// of the operators $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $like, $match and $not. The logical
+// operators $and, $or and $nor take an array of documents, and $not a document. $nor is the negation
+// of $or, e.g. not(or(eq(a,1),eq(b,2))). The members of a document are joined with "and".
+//
+// The special members $sort (an object of fields to 1 or -1, or an array of +field/-field), $select
+// (an array of fields), $limit and $offset (or $skip) are only allowed at the top level.
+//
+// Values are converted to their RQL text, e.g. 10 to "10" and null to "null", and are converted to
+// the field types when the parser has a model, so the result is the same as the result of Parse
+// for the equivalent RQL query.
+func (p *Parser) ParseJSON(r io.Reader) (root *RqlRootNode, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	query := string(b)
+	defer func() {
+		setErrorQuery(err, query)
+	}()
+	if err = p.checkLength(query); err != nil {
+		return nil, err
+	}
+	jr := newJSONReader(b)
+	v, err := jr.read()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := jr.dec.Token(); err != io.EOF {
+		offset := jr.offset()
+		return nil, jsonError(Pos{Start: offset, End: len(query)}, "unexpected data after the filter document")
+	}
+	doc, ok := v.(jsonObject)
+	if !ok {
+		return nil, jsonError(Pos{Start: 0, End: len(query)}, "the filter must be a JSON object")
+	}
+	root = &RqlRootNode{}
+	var filters []jsonMember
+	for _, m := range doc {
+		switch m.Key {
+		case "$sort":
+			root.sorts, err = jsonSort(m)
+		case "$select":
+			root.selects, err = jsonStrings(m)
+		case "$limit":
+			root.limit, err = jsonScalar(m.Value, m.Pos)
+		case "$offset", "$skip":
+			root.offset, err = jsonScalar(m.Value, m.Pos)
+		default:
+			filters = append(filters, m)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if root.Node, err = jsonConjunction(filters, Pos{Start: 0, End: len(query)}); err != nil {
+		return nil, err
+	}
+	if err = p.checkComplexity(root.Node); err != nil {
+		return nil, err
+	}
+	if err = p.checkCost(context.Background(), root); err != nil {
+		return nil, err
+	}
+	if err = p.validate(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// jsonReader reads the JSON values of a document.
+type jsonReader struct {
+	src []byte
+	dec *json.Decoder
+}
+
+func newJSONReader(src []byte) *jsonReader {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	return &jsonReader{src: src, dec: dec}
+}
+
+// offset returns the offset of the next token in the document.
+func (jr *jsonReader) offset() int {
+	i := int(jr.dec.InputOffset())
+	for i < len(jr.src) && strings.IndexByte(" \t\r\n,:", jr.src[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// read reads the next value of the document. Objects are read as jsonObject, arrays as
+// []interface{}, and numbers as json.Number.
+func (jr *jsonReader) read() (interface{}, error) {
+	t, err := jr.dec.Token()
+	if err != nil {
+		return nil, jr.error(err)
+	}
+	switch t {
+	case json.Delim('{'):
+		var obj jsonObject
+		for jr.dec.More() {
+			start := jr.offset()
+			k, err := jr.dec.Token()
+			if err != nil {
+				return nil, jr.error(err)
+			}
+			v, err := jr.read()
+			if err != nil {
+				return nil, err
+			}
+			end := int(jr.dec.InputOffset())
+			obj = append(obj, jsonMember{Key: k.(string), Value: v, Pos: Pos{Start: start, End: end}})
+		}
+		if _, err := jr.dec.Token(); err != nil {
+			return nil, jr.error(err)
+		}
+		return obj, nil
+	case json.Delim('['):
+		arr := []interface{}{}
+		for jr.dec.More() {
+			v, err := jr.read()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		if _, err := jr.dec.Token(); err != nil {
+			return nil, jr.error(err)
+		}
+		return arr, nil
+	}
+	return t, nil
+}
+
+// error converts an error of the decoder to a syntax error.
+func (jr *jsonReader) error(err error) error {
+	offset := int(jr.dec.InputOffset())
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		offset = int(se.Offset)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return &ParseError{Pos: Pos{Start: offset, End: offset}, Err: &SyntaxError{Msg: err.Error()}}
+}
+
+// jsonConjunction returns the node of the filter members, joined with "and" if there are more than one.
+func jsonConjunction(members []jsonMember, pos Pos) (*RqlNode, error) {
+	var args []interface{}
+	for _, m := range members {
+		n, err := jsonFilter(m)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, n)
+	}
+	switch len(args) {
+	case 0:
+		return nil, nil
+	case 1:
+		return args[0].(*RqlNode), nil
+	}
+	return &RqlNode{Op: "and", Args: args, Pos: pos}, nil
+}
+
+// jsonFilter returns the node of a filter member, that is either a logical operator or a field.
+func jsonFilter(m jsonMember) (*RqlNode, error) {
+	switch m.Key {
+	case "$and", "$or", "$nor":
+		docs, ok := m.Value.([]interface{})
+		if !ok || len(docs) == 0 {
+			return nil, jsonError(m.Pos, "%s expects a non-empty array of documents", m.Key)
+		}
+		n := &RqlNode{Op: strings.TrimPrefix(m.Key, "$"), Pos: m.Pos}
+		if m.Key == "$nor" {
+			n.Op = "or"
+		}
+		for _, d := range docs {
+			c, err := jsonDocument(d, m)
+			if err != nil {
+				return nil, err
+			}
+			n.Args = append(n.Args, c)
+		}
+		if m.Key == "$nor" {
+			// the drivers translate a single argument not, e.g. not(or(a,b)) => NOT((a) OR (b)).
+			return &RqlNode{Op: "not", Args: []interface{}{n}, Pos: m.Pos}, nil
+		}
+		return n, nil
+	case "$not":
+		c, err := jsonDocument(m.Value, m)
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "not", Args: []interface{}{c}, Pos: m.Pos}, nil
+	}
+	if strings.HasPrefix(m.Key, "$") {
+		return nil, jsonError(m.Pos, "unknown operator %s", m.Key)
+	}
+	ops, ok := m.Value.(jsonObject)
+	if !ok {
+		v, err := jsonScalar(m.Value, m.Pos)
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "eq", Args: []interface{}{m.Key, v}, Pos: m.Pos}, nil
+	}
+	if len(ops) == 0 {
+		return nil, jsonError(m.Pos, "empty operators document for field %s", m.Key)
+	}
+	var args []interface{}
+	for _, o := range ops {
+		n, err := jsonFieldOp(m.Key, o)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, n)
+	}
+	if len(args) == 1 {
+		return args[0].(*RqlNode), nil
+	}
+	return &RqlNode{Op: "and", Args: args, Pos: m.Pos}, nil
+}
+
+// jsonDocument returns the node of a nested filter document of the member m.
+func jsonDocument(v interface{}, m jsonMember) (*RqlNode, error) {
+	doc, ok := v.(jsonObject)
+	if !ok || len(doc) == 0 {
+		return nil, jsonError(m.Pos, "%s expects non-empty documents", m.Key)
+	}
+	for _, c := range doc {
+		switch c.Key {
+		case "$sort", "$select", "$limit", "$offset", "$skip":
+			return nil, jsonError(c.Pos, "%s is only allowed at the top level", c.Key)
+		}
+	}
+	return jsonConjunction(doc, m.Pos)
+}
+
+// jsonFieldOp returns the node of the operator o applied to the field.
+func jsonFieldOp(field string, o jsonMember) (*RqlNode, error) {
+	switch o.Key {
+	case "$in", "$nin":
+		values, ok := o.Value.([]interface{})
+		if !ok {
+			return nil, jsonError(o.Pos, "%s expects an array of values", o.Key)
+		}
+		group := &RqlNode{Op: "group", Args: []interface{}{field}, Pos: o.Pos}
+		for _, v := range values {
+			s, err := jsonScalar(v, o.Pos)
+			if err != nil {
+				return nil, err
+			}
+			group.Args = append(group.Args, s)
+		}
+		n := &RqlNode{Op: "in", Args: []interface{}{field, group}, Pos: o.Pos}
+		if o.Key == "$nin" {
+			n = &RqlNode{Op: "not", Args: []interface{}{n}, Pos: o.Pos}
+		}
+		return n, nil
+	case "$not":
+		c, err := jsonFilter(jsonMember{Key: field, Value: o.Value, Pos: o.Pos})
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "not", Args: []interface{}{c}, Pos: o.Pos}, nil
+	}
+	op, ok := jsonComparisons[o.Key]
+	if !ok {
+		return nil, jsonError(o.Pos, "unknown operator %s for field %s", o.Key, field)
+	}
+	v, err := jsonScalar(o.Value, o.Pos)
+	if err != nil {
+		return nil, err
+	}
+	return &RqlNode{Op: op, Args: []interface{}{field, v}, Pos: o.Pos}, nil
+}
+
+// jsonScalar returns the RQL text of a scalar JSON value.
+func jsonScalar(v interface{}, pos Pos) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case nil:
+		return "null", nil
+	}
+	return "", jsonError(pos, "expecting a string, number, boolean or null value")
+}
+
+// jsonSort returns the sort fields of the $sort member, either an object of fields to
+// 1 or -1, or an array of fields prefixed with + or -.
+func jsonSort(m jsonMember) (sorts []Sort, err error) {
+	if fields, ok := m.Value.([]interface{}); ok {
+		n := &RqlNode{Op: SortOp, Args: fields}
+		for _, f := range fields {
+			if _, ok := f.(string); !ok {
+				return nil, jsonError(m.Pos, "$sort expects an array of fields")
+			}
+		}
+		root := &RqlRootNode{}
+		parseSort(n, root)
+		return root.sorts, nil
+	}
+	fields, ok := m.Value.(jsonObject)
+	if !ok {
+		return nil, jsonError(m.Pos, "$sort expects an object or an array of fields")
+	}
+	for _, f := range fields {
+		switch d, _ := f.Value.(json.Number); d {
+		case "1":
+			sorts = append(sorts, Sort{By: f.Key})
+		case "-1":
+			sorts = append(sorts, Sort{By: f.Key, Desc: true})
+		default:
+			return nil, jsonError(f.Pos, "the sort direction of %s must be 1 or -1", f.Key)
+		}
+	}
+	return sorts, nil
+}
+
+// jsonStrings returns the strings of an array member.
+func jsonStrings(m jsonMember) ([]string, error) {
+	values, ok := m.Value.([]interface{})
+	if !ok {
+		return nil, jsonError(m.Pos, "%s expects an array of fields", m.Key)
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		if out[i], ok = v.(string); !ok {
+			return nil, jsonError(m.Pos, "%s expects an array of fields", m.Key)
+		}
+	}
+	return out, nil
+}
+
+// jsonError returns a syntax error at the given span of the document.
+func jsonError(pos Pos, format string, args ...interface{}) error {
+	return &ParseError{Pos: pos, Err: &SyntaxError{Msg: fmt.Sprintf(format, args...)}}
+}
@@ -0,0 +1,190 @@
+package gorql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func FuzzParseJSON(f *testing.F) {
+	f.Add(`{"$and":[{"price":{"$gt":10}},{"tags":{"$in":["a","b"]}}],"$sort":{"price":-1},"$limit":10}`)
+	f.Fuzz(func(t *testing.T, a string) {
+		p, err := NewParser(nil)
+		if err != nil {
+			t.Fatalf("New parser error :%s", err)
+		}
+		_, _ = p.ParseJSON(strings.NewReader(a))
+	})
+}
+
+type JSONTest struct {
+	Name     string      // Name of the test
+	JSON     string      // Input JSON filter document
+	Model    interface{} // Input Model for query
+	Expected string      // Expected canonical RQL
+	WantErr  bool        // Expected parse error
+}
+
+var jsonTests = []JSONTest{
+	{
+		Name:     `Logical operators and special members`,
+		JSON:     `{"$and":[{"price":{"$gt":10}},{"tags":{"$in":["a","b"]}}],"$sort":{"price":-1,"name":1},"$limit":10}`,
+		Expected: `gt(price,10)&in(tags,[a,b])&sort(-price,+name)&limit(10)`,
+	},
+	{
+		Name:     `Implicit equality and conjunction of members`,
+		JSON:     `{"name":"Smith, John","active":true,"deleted":null}`,
+		Expected: `eq(name,"Smith, John")&eq(active,true)&eq(deleted,null)`,
+	},
+	{
+		Name:     `Conjunction of field operators`,
+		JSON:     `{"price":{"$gte":10,"$lt":20.5}}`,
+		Expected: `ge(price,10)&lt(price,20.5)`,
+	},
+	{
+		Name:     `Or, nor and not`,
+		JSON:     `{"$or":[{"a":1},{"b":{"$ne":2}}],"$nor":[{"c":3},{"d":4}],"e":{"$not":{"$like":"x*"}}}`,
+		Expected: `or(eq(a,1),ne(b,2))&not(or(eq(c,3),eq(d,4)))&not(like(e,x*))`,
+	},
+	{
+		Name:     `Not in`,
+		JSON:     `{"status":{"$nin":["a","b"]}}`,
+		Expected: `not(in(status,[a,b]))`,
+	},
+	{
+		Name:     `Values are not typed literals`,
+		JSON:     `{"code":"number:5"}`,
+		Expected: `eq(code,string:number:5)`,
+	},
+	{
+		Name:     `Sort array, select, offset`,
+		JSON:     `{"$sort":["-price","+name"],"$select":["name","price"],"$skip":20}`,
+		Expected: `sort(-price,+name)&select(name,price)&offset(20)`,
+	},
+	{
+		Name:     `Empty document`,
+		JSON:     `{}`,
+		Expected: ``,
+	},
+	{
+		Name: `Model validation`,
+		JSON: `{"age":{"$gte":18},"name":"foo","$sort":{"age":-1}}`,
+		Model: new(struct {
+			Age  int    `rql:"filter,sort"`
+			Name string `rql:"filter"`
+		}),
+		Expected: `ge(age,18)&eq(name,foo)&sort(-age)`,
+	},
+	{
+		Name: `Unknown field`,
+		JSON: `{"size":1}`,
+		Model: new(struct {
+			Age int `rql:"filter"`
+		}),
+		WantErr: true,
+	},
+	{
+		Name:    `Malformed JSON`,
+		JSON:    `{"price":{"$gt":10}`,
+		WantErr: true,
+	},
+	{
+		Name:    `Not an object`,
+		JSON:    `[{"price":1}]`,
+		WantErr: true,
+	},
+	{
+		Name:    `Unknown operator`,
+		JSON:    `{"price":{"$between":[1,2]}}`,
+		WantErr: true,
+	},
+	{
+		Name:    `Array value`,
+		JSON:    `{"tags":["a","b"]}`,
+		WantErr: true,
+	},
+	{
+		Name:    `Nested special member`,
+		JSON:    `{"$or":[{"$limit":10}]}`,
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid sort direction`,
+		JSON:    `{"$sort":{"price":"down"}}`,
+		WantErr: true,
+	},
+	{
+		Name:    `Trailing data`,
+		JSON:    `{"a":1} {"b":2}`,
+		WantErr: true,
+	},
+}
+
+func TestParseJSON(t *testing.T) {
+	for _, test := range jsonTests {
+		test.Run(t)
+	}
+}
+
+func (test JSONTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.ParseJSON(strings.NewReader(test.JSON))
+	if test.WantErr != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantErr, err)
+	}
+	if err != nil {
+		return
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestParseJSONMatchesParse(t *testing.T) {
+	p, err := NewParser(&Config{Model: new(struct {
+		Price float64 `rql:"filter,sort"`
+		Tags  string  `rql:"filter"`
+	})})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	doc, err := p.ParseJSON(strings.NewReader(`{"price":{"$gt":10},"tags":{"$in":["a","b"]},"$sort":{"price":-1},"$limit":10}`))
+	if err != nil {
+		t.Fatalf("ParseJSON error: %v", err)
+	}
+	rql, err := p.Parse(strings.NewReader(`and(gt(price,10),in(tags,[a,b]))`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	clearPos(doc.Node)
+	clearPos(rql.Node)
+	if !reflect.DeepEqual(doc.Node, rql.Node) {
+		t.Fatalf("Expecting the tree %s, got %s", rql.Node, doc.Node)
+	}
+	if sorts := doc.Sort(); !reflect.DeepEqual(sorts, []Sort{{By: "price", Desc: true}}) || doc.Limit() != "10" {
+		t.Fatalf("Unexpected special operations: %s", doc)
+	}
+}
+
+func TestParseJSONErrorPosition(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	_, err = p.ParseJSON(strings.NewReader(`{"a":1, "b":{"$nope":2}}`))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code() != CodeSyntax {
+		t.Fatalf("Expecting a syntax error, got: %v", err)
+	}
+	if expected := "{\"a\":1, \"b\":{\"$nope\":2}}\n             ^^^^^^^^^"; pe.Snippet() != expected {
+		t.Fatalf("Unexpected error snippet:\n%s", pe.Snippet())
+	}
+}
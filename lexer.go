@@ -1,11 +1,13 @@
 package gorql
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
 	"io"
 	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -14,7 +16,8 @@ const (
 	Eof
 
 	// Literals
-	Ident // fields, function names
+	Ident  // fields, function names
+	String // quoted values, e.g. "Smith, John"
 
 	// Reserved characters
 	Space                //
@@ -50,24 +53,52 @@ var (
 type Token int
 
 type TokenString struct {
-	t Token
-	s string
+	t   Token
+	s   string
+	pos Pos
+}
+
+// Pos is a span of bytes in the original query. Start is the offset of the first byte,
+// and End is the offset right after the last byte.
+type Pos struct {
+	Start int
+	End   int
+}
+
+// Pos returns the position of the token in the scanned query.
+func (t TokenString) Pos() Pos {
+	return t.pos
 }
 
 func NewTokenString(t Token, s string) TokenString {
-	unescapedString := ""
-	if len(s) > 0 && string(s[0]) != "+" {
-		unescapedString, _ = url.QueryUnescape(s)
-	} else {
+	return TokenString{t: t, s: unescapeToken(s)}
+}
+
+// unescapeToken returns the percent-decoded literal of a token.
+func unescapeToken(s string) string {
+	if strings.IndexByte(s, '%') < 0 && strings.IndexByte(s, '+') < 0 {
+		return s
+	}
+	if s[0] == '+' {
 		//Golang`s "unescape" method replaces "+" with " ", however "+" literal is not possible in urlencoded string
 		//this is a case of sorting argument specification: eg: sort(+name), thus in this case string left unmodified
-		unescapedString = s
+		return s
 	}
-	return TokenString{t: t, s: unescapedString}
+	unescaped, err := url.QueryUnescape(s)
+	if err != nil {
+		// malformed escape sequences are kept as they are.
+		return s
+	}
+	return unescaped
 }
 
 type Scanner struct {
-	r *bufio.Reader
+	src string
+	// offset is the offset of the next rune in src, and last is the size of the last rune read.
+	offset int
+	last   int
+	// msg describes why the last token is illegal, if it is not the token itself.
+	msg string
 }
 
 func NewScanner() *Scanner {
@@ -76,50 +107,105 @@ func NewScanner() *Scanner {
 
 // Scan returns the next token and literal value.
 func (s *Scanner) Scan(r io.Reader) (out []TokenString, err error) {
-	s.r = bufio.NewReader(r)
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.scan(string(b))
+}
 
+// scan returns the tokens of the query. The literals of the tokens are slices of the query,
+// and are copied only when they need to be decoded.
+func (s *Scanner) scan(query string) (out []TokenString, err error) {
+	s.src, s.offset, s.last = query, 0, 0
+	out = make([]TokenString, 0, maxTokens(query))
 	for {
+		start := s.offset
+		s.msg = ""
 		tok, lit := s.ScanToken()
+		pos := Pos{Start: start, End: s.offset}
 		if tok == Eof {
 			break
 		} else if tok == Illegal {
-			return out, fmt.Errorf("illegal Token : %s", lit)
+			msg := s.msg
+			if msg == "" {
+				msg = fmt.Sprintf("illegal Token : %s", lit)
+			}
+			return out, &ParseError{Pos: pos, Err: &SyntaxError{Msg: msg}}
+		} else if tok == Ident {
+			out = append(out, TokenString{t: tok, s: unescapeToken(lit), pos: pos})
 		} else {
-			out = append(out, NewTokenString(tok, lit))
+			// quoted values are taken literally, and are not percent-decoded.
+			out = append(out, TokenString{t: tok, s: lit, pos: pos})
 		}
 	}
 
 	return
 }
 
+// maxTokens returns the maximum number of tokens of a query. The reserved runes are single tokens,
+// and separate the other tokens.
+func maxTokens(query string) int {
+	n := 1
+	for i := 0; i < len(query); i++ {
+		if query[i] < utf8.RuneSelf && isReservedRune(rune(query[i])) {
+			n += 2
+		}
+	}
+	if n > len(query) {
+		return len(query)
+	}
+	return n
+}
+
 func (s *Scanner) ScanToken() (tok Token, lit string) {
 	ch := s.read()
 
 	if isReservedRune(ch) {
 		s.unread()
 		return s.scanReservedRune()
+	} else if isQuote(ch) {
+		s.unread()
+		return s.scanString()
 	} else if isIdent(ch) {
 		s.unread()
 		return s.scanIdent()
 	}
 
-	if ch == eof {
+	if s.atEOF(ch) {
 		return Eof, ""
 	}
+	if ch == utf8.RuneError && s.last == 1 {
+		s.msg = "invalid UTF-8 encoding"
+	}
 
 	return Illegal, string(ch)
 }
 
 func (s *Scanner) read() rune {
-	ch, _, err := s.r.ReadRune()
-	if err != nil {
+	if s.offset >= len(s.src) {
+		s.last = 0
 		return eof
 	}
+	ch, size := rune(s.src[s.offset]), 1
+	if ch >= utf8.RuneSelf {
+		ch, size = utf8.DecodeRuneInString(s.src[s.offset:])
+	}
+	s.offset += size
+	s.last = size
 	return ch
 }
 
+// atEOF reports whether the rune returned by read is the end of the input, and not a NUL byte.
+func (s *Scanner) atEOF(ch rune) bool {
+	return ch == eof && s.last == 0
+}
+
 // unread places the previously read rune back on the reader.
-func (s *Scanner) unread() { _ = s.r.UnreadRune() }
+func (s *Scanner) unread() {
+	s.offset -= s.last
+	s.last = 0
+}
 
 func isReservedRune(ch rune) bool {
 	for _, rr := range ReservedRunes {
@@ -131,52 +217,53 @@ func isReservedRune(ch rune) bool {
 }
 
 func (s *Scanner) scanReservedRune() (tok Token, lit string) {
-	// Create a buffer and read the current character into it.
-	var buf bytes.Buffer
-
-	buf.WriteRune(s.read())
-	lit = buf.String()
-
-	// Read every subsequent whitespace character into the buffer.
-	// Non-whitespace characters and EOF will cause the loop to exit.
-
-	for _, rr := range ReservedRunes {
-		if string(rr) == lit {
-			switch rr {
-			case '&':
-				return Ampersand, lit
-			case '(':
-				return OpeningParenthesis, lit
-			case ')':
-				return ClosingParenthesis, lit
-			case ',':
-				return Comma, lit
-			case '=':
-				return EqualSign, lit
-			case '/':
-				return Slash, lit
-			case ';':
-				return SemiColon, lit
-			case '?':
-				return QuestionMark, lit
-			case '|':
-				return Pipe, lit
-			case '[':
-				return OpeningSquareBracket, lit
-			case ']':
-				return ClosingSquareBracket, lit
-			case eof:
-				return Eof, lit
-			default:
-				return Illegal, lit
-			}
-		}
+	start := s.offset
+	ch := s.read()
+	lit = s.src[start:s.offset]
+	switch ch {
+	case '&':
+		return Ampersand, lit
+	case '(':
+		return OpeningParenthesis, lit
+	case ')':
+		return ClosingParenthesis, lit
+	case ',':
+		return Comma, lit
+	case '=':
+		return EqualSign, lit
+	case '/':
+		return Slash, lit
+	case ';':
+		return SemiColon, lit
+	case '?':
+		return QuestionMark, lit
+	case '|':
+		return Pipe, lit
+	case '[':
+		return OpeningSquareBracket, lit
+	case ']':
+		return ClosingSquareBracket, lit
 	}
 	return Illegal, lit
 }
 
-// isIdent returns true if the rune is an identifier
+// isQuote returns true if the rune opens a quoted string.
+func isQuote(ch rune) bool {
+	return ch == '"' || ch == '\''
+}
+
+// isValueToken returns true if the token can be used as a value.
+func isValueToken(t Token) bool {
+	return t == Ident || t == String
+}
+
+// isIdent returns true if the rune is an identifier. Unlike IsLetter and IsDigit, the scanner
+// accepts the letters and digits of any script, and the combining marks, like the accents of
+// decomposed letters.
 func isIdent(ch rune) bool {
+	if ch >= utf8.RuneSelf {
+		return unicode.IsLetter(ch) || unicode.IsDigit(ch) || unicode.IsMark(ch)
+	}
 	return IsLetter(ch) || IsDigit(ch) || isSpecialChar(ch)
 }
 
@@ -187,31 +274,77 @@ func isSpecialChar(ch rune) bool {
 		ch == ':' || ch == '$' || ch == '@'
 }
 
-// IsLetter returns true if the rune is a letter.
+// IsLetter returns true if the rune is an ASCII letter.
 func IsLetter(ch rune) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
 }
 
-// IsDigit returns true if the rune is a digit.
+// IsDigit returns true if the rune is an ASCII digit.
 func IsDigit(ch rune) bool { return ch >= '0' && ch <= '9' }
 
 func (s *Scanner) scanIdent() (tok Token, lit string) {
-	// Create a buffer and read the current character into it.
-	var buf bytes.Buffer
-	buf.WriteRune(s.read())
+	start := s.offset
+	s.read()
 
-	// Read every subsequent ident character into the buffer.
+	// Read every subsequent ident character.
 	// Non-ident characters and EOF will cause the loop to exit.
 	for {
-		if ch := s.read(); ch == eof {
+		if ch := s.read(); s.atEOF(ch) {
 			break
 		} else if !isIdent(ch) {
 			s.unread()
 			break
-		} else {
-			_, _ = buf.WriteRune(ch)
 		}
 	}
 
-	return Ident, buf.String()
+	return Ident, s.src[start:s.offset]
+}
+
+// scanString scans a string quoted with single or double quotes, and returns its unquoted value.
+// The backslash escapes of Go string literals are supported, e.g. \" or \n.
+func (s *Scanner) scanString() (tok Token, lit string) {
+	start := s.offset
+	quote := s.read()
+	escaped := false
+	for {
+		ch := s.read()
+		switch {
+		case s.atEOF(ch):
+			s.msg = fmt.Sprintf("unterminated string : %s", s.src[start:s.offset])
+			return Illegal, s.src[start:s.offset]
+		case ch == quote:
+			body := s.src[start+1 : s.offset-1]
+			if !escaped && utf8.ValidString(body) {
+				return String, body
+			}
+			v, err := unquote(body, byte(quote))
+			if err != nil {
+				s.msg = fmt.Sprintf("invalid escape sequence in string : %s", s.src[start:s.offset])
+				return Illegal, s.src[start:s.offset]
+			}
+			return String, v
+		case ch == '\\':
+			escaped = true
+			s.read()
+		}
+	}
+}
+
+// unquote returns the value of the body of a string quoted with the given quote,
+// with its backslash escapes replaced.
+func unquote(body string, quote byte) (string, error) {
+	var out strings.Builder
+	for len(body) > 0 {
+		r, multibyte, tail, err := strconv.UnquoteChar(body, quote)
+		if err != nil {
+			return "", err
+		}
+		if r < utf8.RuneSelf || multibyte {
+			out.WriteRune(r)
+		} else {
+			out.WriteByte(byte(r))
+		}
+		body = tail
+	}
+	return out.String(), nil
 }
@@ -0,0 +1,223 @@
+package gorql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type QuotedStringTest struct {
+	Name     string   // Name of the test
+	RQL      string   // Input RQL query
+	Expected []string // Expected arguments of the parsed node
+	WantErr  bool     // Expected syntax error
+}
+
+var quotedStringTests = []QuotedStringTest{
+	{
+		Name:     `Double quotes with reserved runes`,
+		RQL:      `eq(name,"Smith, John (Jr) & co")`,
+		Expected: []string{"name", "Smith, John (Jr) & co"},
+	},
+	{
+		Name:     `Single quotes`,
+		RQL:      `eq(name,'a|b;c=d')`,
+		Expected: []string{"name", "a|b;c=d"},
+	},
+	{
+		Name:     `Backslash escapes`,
+		RQL:      `eq(name,"say \"hi\"\t\\ ü")`,
+		Expected: []string{"name", "say \"hi\"\t\\ ü"},
+	},
+	{
+		Name:     `Quoted values are not percent-decoded`,
+		RQL:      `eq(name,"50%25 + 1")`,
+		Expected: []string{"name", "50%25 + 1"},
+	},
+	{
+		Name:     `Quoted values are not typed`,
+		RQL:      `eq(name,"number:5")`,
+		Expected: []string{"name", "number:5"},
+	},
+	{
+		Name:     `Empty quoted value`,
+		RQL:      `eq(name,'')`,
+		Expected: []string{"name", ""},
+	},
+	{
+		Name:     `Simple equal bloc`,
+		RQL:      `name="a,b"`,
+		Expected: []string{"name", "a,b"},
+	},
+	{
+		Name:    `Unterminated string`,
+		RQL:     `eq(name,"Smith)`,
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid escape sequence`,
+		RQL:     `eq(name,"\q")`,
+		WantErr: true,
+	},
+}
+
+func TestQuotedStrings(t *testing.T) {
+	for _, test := range quotedStringTests {
+		test.Run(t)
+	}
+}
+
+func (test QuotedStringTest) Run(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if test.WantErr {
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Fatalf("(%s) Expected a syntax error, got: %v", test.Name, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error: %v", test.Name, err)
+	}
+	var args []string
+	for _, a := range root.Node.Args {
+		args = append(args, a.(string))
+	}
+	if !reflect.DeepEqual(args, test.Expected) {
+		t.Fatalf("(%s) Parsed arguments doesn't match: %q vs %q", test.Name, args, test.Expected)
+	}
+}
+
+func TestQuotedStringPosition(t *testing.T) {
+	ts, err := NewScanner().Scan(strings.NewReader(`eq(a,"x, y")`))
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if len(ts) != 6 || ts[4].t != String {
+		t.Fatalf("Expecting a single string token, got: %v", ts)
+	}
+	if pos := ts[4].Pos(); pos != (Pos{Start: 5, End: 11}) {
+		t.Fatalf("Unexpected position of the string token: %+v", pos)
+	}
+}
+
+func FuzzScanUnicode(f *testing.F) {
+	for _, s := range []string{"eq(city,Zürich)", "eq(name,東京タワー)&gt(年齢,30)", "eq(name,é)", "eq(a,\xff\xfe)", "eq(a,Z%C3%BC)", "eq(a,\"😀\")"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, a string) {
+		ts, err := NewScanner().Scan(strings.NewReader(a))
+		end := 0
+		for _, tok := range ts {
+			if tok.pos.Start < end || tok.pos.End <= tok.pos.Start || tok.pos.End > len(a) {
+				t.Fatalf("Invalid position %+v of token %q in %q", tok.pos, tok.s, a)
+			}
+			end = tok.pos.End
+		}
+		if err == nil && end != len(a) {
+			t.Fatalf("Tokens end at %d, expecting %d in %q", end, len(a), a)
+		}
+	})
+}
+
+type UnicodeTest struct {
+	Name     string // Name of the test
+	RQL      string // Input RQL query
+	Expected string // Expected canonical RQL
+	WantErr  bool   // Expected syntax error
+}
+
+var unicodeTests = []UnicodeTest{
+	{
+		Name:     `Latin letters`,
+		RQL:      `eq(city,Zürich)`,
+		Expected: `eq(city,Zürich)`,
+	},
+	{
+		Name:     `Japanese identifiers and values`,
+		RQL:      `eq(名前,東京タワー)&ne(種類,ビル)`,
+		Expected: `eq(名前,東京タワー)&ne(種類,ビル)`,
+	},
+	{
+		Name:     `Combining marks`,
+		RQL:      `eq(name,नमस्ते)|eq(name,Cafe` + "́" + `)`,
+		Expected: `or(eq(name,नमस्ते),eq(name,Cafe` + "́" + `))`,
+	},
+	{
+		Name:     `Non-ASCII digits`,
+		RQL:      `eq(code,٣٤)`,
+		Expected: `eq(code,٣٤)`,
+	},
+	{
+		Name:     `Percent-encoded letters`,
+		RQL:      `eq(city,Z%C3%BCrich)`,
+		Expected: `eq(city,Zürich)`,
+	},
+	{
+		Name:     `Quoted symbols`,
+		RQL:      `eq(name,"😀 ok")`,
+		Expected: `eq(name,"😀 ok")`,
+	},
+	{
+		Name:    `Unquoted symbols`,
+		RQL:     `eq(name,😀)`,
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid UTF-8`,
+		RQL:     "eq(name,Z\xfcrich)",
+		WantErr: true,
+	},
+}
+
+func TestUnicode(t *testing.T) {
+	for _, test := range unicodeTests {
+		test.Run(t)
+	}
+}
+
+func (test UnicodeTest) Run(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if test.WantErr {
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Fatalf("(%s) Expected a syntax error, got: %v", test.Name, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error: %v", test.Name, err)
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestIsValidFieldASCII(t *testing.T) {
+	for _, name := range []string{"naïve", "名前", "٣٤", "Cafe\u0301"} {
+		if IsValidField(name) {
+			t.Fatalf("Expecting IsValidField(%q) to reject the non-ASCII name", name)
+		}
+	}
+	if !IsValidField("address.city_2") {
+		t.Fatal("Expecting IsValidField to accept an ASCII name")
+	}
+}
+
+func TestInvalidUTF8Position(t *testing.T) {
+	query := "eq(städte,\xff)"
+	_, err := NewScanner().Scan(strings.NewReader(query))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Pos != (Pos{Start: 11, End: 12}) || !strings.Contains(err.Error(), "invalid UTF-8") {
+		t.Fatalf("Expecting an invalid UTF-8 error at offset 11, got: %v", err)
+	}
+}
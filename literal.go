@@ -0,0 +1,87 @@
+package gorql
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// literalFunc converts the text of a typed literal to its value.
+type literalFunc func(string) (interface{}, error)
+
+// typedLiterals holds the value prefixes of the RQL specification, and the conversion of their text.
+// For example, the value "number:10" is converted into the float64 10, and "string:10" into the string "10".
+//
+//	string:  string
+//	number:  float64
+//	boolean: bool
+//	date:    time.Time, either RFC3339 or a date in the format 2006-01-02
+//	epoch:   time.Time, from the number of milliseconds since the Unix epoch
+//	re:      *regexp.Regexp
+var typedLiterals = map[string]literalFunc{
+	"string": func(s string) (interface{}, error) {
+		return s, nil
+	},
+	"number": func(s string) (interface{}, error) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errors.New("not a number")
+		}
+		return f, nil
+	},
+	"boolean": func(s string) (interface{}, error) {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, errors.New("not a boolean")
+		}
+		return b, nil
+	},
+	"date": func(s string) (interface{}, error) {
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, errors.New("not an RFC3339 date")
+	},
+	"epoch": func(s string) (interface{}, error) {
+		ms, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errors.New("not a number of milliseconds")
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	},
+	"re": func(s string) (interface{}, error) {
+		return regexp.Compile(s)
+	},
+}
+
+// splitTypedLiteral splits the value into its type prefix and text.
+// It returns false if the value is not a typed literal.
+func splitTypedLiteral(s string) (prefix, text string, ok bool) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	if _, ok := typedLiterals[s[:i]]; !ok {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+// tokenValue returns the value of a single token argument. Typed literals
+// are converted to their type, and other values, including quoted strings,
+// are returned as strings.
+func tokenValue(t TokenString) (interface{}, error) {
+	prefix, text, ok := splitTypedLiteral(t.s)
+	if !ok || t.t == String {
+		return t.s, nil
+	}
+	v, err := typedLiterals[prefix](text)
+	if err != nil {
+		return nil, &ParseError{Pos: t.pos, Err: &InvalidValueError{Value: t.s, Err: err}}
+	}
+	return v, nil
+}
@@ -0,0 +1,180 @@
+package gorql
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type LiteralTest struct {
+	Name     string      // Name of the test
+	RQL      string      // Input RQL query
+	Model    interface{} // Input Model for query
+	Expected *RqlNode    // Expected filter node
+	WantErr  bool        // Expected error
+}
+
+type literalModel struct {
+	Name      string    `rql:"filter"`
+	Age       int       `rql:"filter"`
+	Admin     bool      `rql:"filter"`
+	CreatedAt time.Time `rql:"filter"`
+}
+
+var literalTests = []LiteralTest{
+	{
+		Name: `Typed values without a model`,
+		RQL:  `and(eq(a,number:1.5),eq(b,boolean:true),eq(c,date:2020-01-02),eq(d,epoch:1000),eq(e,string:42))`,
+		Expected: &RqlNode{
+			Op: "and",
+			Args: []interface{}{
+				&RqlNode{Op: "eq", Args: []interface{}{"a", 1.5}},
+				&RqlNode{Op: "eq", Args: []interface{}{"b", true}},
+				&RqlNode{Op: "eq", Args: []interface{}{"c", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}},
+				&RqlNode{Op: "eq", Args: []interface{}{"d", time.Date(1970, 1, 1, 0, 0, 1, 0, time.UTC)}},
+				&RqlNode{Op: "eq", Args: []interface{}{"e", "42"}},
+			},
+		},
+	},
+	{
+		Name: `Typed values in simple equal and array blocs`,
+		RQL:  `a=number:3&in(b,[number:1,string:2])`,
+		Expected: &RqlNode{
+			Op: "AND",
+			Args: []interface{}{
+				&RqlNode{Op: "eq", Args: []interface{}{"a", float64(3)}},
+				&RqlNode{Op: "in", Args: []interface{}{"b", &RqlNode{Op: "group", Args: []interface{}{"b", float64(1), "2"}}}},
+			},
+		},
+	},
+	{
+		Name: `Unknown prefixes are kept as strings`,
+		RQL:  `eq(a,foo:bar)`,
+		Expected: &RqlNode{
+			Op:   "eq",
+			Args: []interface{}{"a", "foo:bar"},
+		},
+	},
+	{
+		Name:    `Invalid number`,
+		RQL:     `eq(a,number:abc)`,
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid boolean`,
+		RQL:     `a=boolean:yes`,
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid regular expression`,
+		RQL:     `match(a,re:%5B)`,
+		WantErr: true,
+	},
+	{
+		Name:  `Numbers are converted to the field type`,
+		RQL:   `eq(age,number:42)`,
+		Model: literalModel{},
+		Expected: &RqlNode{
+			Op:   "eq",
+			Args: []interface{}{"age", 42},
+		},
+	},
+	{
+		Name:  `Strings are not converted`,
+		RQL:   `eq(name,string:123)`,
+		Model: literalModel{},
+		Expected: &RqlNode{
+			Op:   "eq",
+			Args: []interface{}{"name", "123"},
+		},
+	},
+	{
+		Name:    `Number on a string field`,
+		RQL:     `eq(name,number:5)`,
+		Model:   literalModel{},
+		WantErr: true,
+	},
+	{
+		Name:    `Boolean on an int field`,
+		RQL:     `eq(age,boolean:true)`,
+		Model:   literalModel{},
+		WantErr: true,
+	},
+	{
+		Name:    `Date on a bool field`,
+		RQL:     `eq(admin,date:2020-01-02)`,
+		Model:   literalModel{},
+		WantErr: true,
+	},
+	{
+		Name:  `Date on a time field`,
+		RQL:   `gt(createdAt,date:2020-01-02)`,
+		Model: literalModel{},
+		Expected: &RqlNode{
+			Op:   "gt",
+			Args: []interface{}{"createdAt", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+	},
+}
+
+func TestTypedLiterals(t *testing.T) {
+	for _, test := range literalTests {
+		test.Run(t)
+	}
+}
+
+func (test LiteralTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if test.WantErr {
+		var ive *InvalidValueError
+		if !errors.As(err, &ive) {
+			t.Fatalf("(%s) Expected an InvalidValueError, got: %v", test.Name, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error: %v", test.Name, err)
+	}
+	clearPos(root.Node)
+	if !reflect.DeepEqual(root.Node, test.Expected) {
+		t.Fatalf("(%s) Parsed node doesn't match: %v vs %v", test.Name, root.Node, test.Expected)
+	}
+}
+
+func TestRegexLiteral(t *testing.T) {
+	root := mustParse(t, `match(name,re:%5Efoo.%2A)`)
+	re, ok := root.Node.Args[1].(*regexp.Regexp)
+	if !ok {
+		t.Fatalf("Expected a *regexp.Regexp, got: %T", root.Node.Args[1])
+	}
+	if re.String() != "^foo.*" {
+		t.Fatalf("Unexpected pattern: %s", re)
+	}
+	if got, want := root.String(), "match(name,re:%5Efoo.*)"; got != want {
+		t.Fatalf("Encoded query doesn't match: %s vs %s", got, want)
+	}
+}
+
+// clearPos resets the positions of the node tree, to compare it with the expected nodes.
+func clearPos(n *RqlNode) {
+	if n == nil {
+		return
+	}
+	Inspect(n, func(n *RqlNode) bool {
+		if n != nil {
+			n.Pos = Pos{}
+		}
+		return true
+	})
+}
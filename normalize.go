@@ -0,0 +1,340 @@
+package gorql
+
+import (
+	"sort"
+	"strings"
+)
+
+// negations maps the comparison operations to their negation.
+var negations = map[string]string{
+	"eq": "ne",
+	"ne": "eq",
+	"lt": "ge",
+	"ge": "lt",
+	"gt": "le",
+	"le": "gt",
+}
+
+// Normalize rewrites the filter of the root node into a canonical form, so drivers emit
+// smaller queries and identical queries produce identical trees:
+//
+//   - operations are lower cased, and nested and/or operations are flattened.
+//   - not operations are pushed down to the predicates using De Morgan's laws, and
+//     negated comparisons are replaced with their complement, e.g. not(lt(a,1)) => ge(a,1).
+//   - duplicate predicates are removed, and the arguments of and/or are sorted.
+//   - lower and upper bounds on the same field are merged into the tightest range, and
+//     a range of a single value is replaced with an equality.
+//
+// Normalize returns a *ContradictionError if the filter can never be satisfied, for example:
+// eq(a,x)&ne(a,x), or and(gt(a,5),lt(a,3)) for a numeric a. In this case, the root node is left
+// unchanged. Note that values are compared numerically if both are numbers, and chronologically
+// if both are times. The bounds of strings are not merged, so numbers should be typed, by the
+// model or a "number:" prefix. Normalize does not know the types of the fields, so strings are
+// equal only if they are identical: eq(a,1)&eq(a,1.0) is left as is, as a numeric column matches
+// both. Use Parser.Normalize to compare them with the types of the model.
+func Normalize(root *RqlRootNode) error {
+	return normalizer{}.normalize(root)
+}
+
+// Normalize is like the Normalize function, but the strings of the string fields of the model
+// are compared for equality, even if they are numeric, e.g. eq(zip,01234)&eq(zip,1234) can never
+// be satisfied for a string zip.
+func (p *Parser) Normalize(root *RqlRootNode) error {
+	return normalizer{p: p}.normalize(root)
+}
+
+// normalizer holds the fields of the model, whose types tell how the strings are compared.
+type normalizer struct {
+	p *Parser
+}
+
+func (nz normalizer) normalize(root *RqlRootNode) error {
+	if root == nil || root.Node == nil {
+		return nil
+	}
+	n, field, ok := nz.simplify(pushNot(root.Node, false))
+	if !ok {
+		return &ContradictionError{Field: field}
+	}
+	root.Node = n
+	return nil
+}
+
+// nodeArgs returns the arguments of the node if all of them are nodes.
+func nodeArgs(n *RqlNode) ([]*RqlNode, bool) {
+	children := make([]*RqlNode, 0, len(n.Args))
+	for _, a := range n.Args {
+		c, ok := a.(*RqlNode)
+		if !ok || c == nil {
+			return nil, false
+		}
+		children = append(children, c)
+	}
+	return children, true
+}
+
+// pushNot returns the lower cased copy of the node, negated if negate is true,
+// with the not operations pushed down to the predicates.
+func pushNot(n *RqlNode, negate bool) *RqlNode {
+	op := strings.ToLower(n.Op)
+	children, ok := nodeArgs(n)
+	switch {
+	case (op == "and" || op == "or") && ok:
+		if negate {
+			op = map[string]string{"and": "or", "or": "and"}[op]
+		}
+		args := make([]interface{}, len(children))
+		for i, c := range children {
+			args[i] = pushNot(c, negate)
+		}
+		return &RqlNode{Op: op, Args: args, Pos: n.Pos}
+	case op == "not" && ok && len(children) > 0:
+		// not(a,b) excludes the records that match any of its arguments.
+		inner := children[0]
+		if len(children) > 1 {
+			inner = &RqlNode{Op: "or", Args: n.Args, Pos: n.Pos}
+		}
+		return pushNot(inner, !negate)
+	}
+	c := *n
+	c.Op = op
+	if !negate {
+		return &c
+	}
+	if neg, ok := negations[op]; ok && isComparison(&c) {
+		c.Op = neg
+		return &c
+	}
+	return &RqlNode{Op: "not", Args: []interface{}{&c}, Pos: n.Pos}
+}
+
+// isComparison reports whether the node is a comparison of a field with a single value.
+func isComparison(n *RqlNode) bool {
+	if _, ok := negations[n.Op]; !ok || len(n.Args) != 2 {
+		return false
+	}
+	if _, ok := n.Args[0].(string); !ok {
+		return false
+	}
+	_, isNode := n.Args[1].(*RqlNode)
+	return !isNode
+}
+
+// simplify flattens, deduplicates, merges and sorts the and/or operations in the tree.
+// It returns false, and the field of the contradicting predicates if the node can never
+// be satisfied.
+func (nz normalizer) simplify(n *RqlNode) (*RqlNode, string, bool) {
+	children, ok := nodeArgs(n)
+	if (n.Op != "and" && n.Op != "or") || !ok {
+		return n, "", true
+	}
+	var (
+		args       []*RqlNode
+		seen       = make(map[string]bool)
+		unsatField string
+	)
+	for _, c := range children {
+		s, field, ok := nz.simplify(c)
+		if !ok {
+			if n.Op == "and" {
+				return nil, field, false
+			}
+			// a branch that is never satisfied does not contribute to the union.
+			if unsatField == "" {
+				unsatField = field
+			}
+			continue
+		}
+		flattened := []*RqlNode{s}
+		if s.Op == n.Op {
+			flattened, _ = nodeArgs(s)
+		}
+		for _, f := range flattened {
+			if key := f.String(); !seen[key] {
+				seen[key] = true
+				args = append(args, f)
+			}
+		}
+	}
+	if len(args) == 0 {
+		return nil, unsatField, false
+	}
+	if n.Op == "and" {
+		var field string
+		if args, field, ok = nz.mergeRanges(args); !ok {
+			return nil, field, false
+		}
+	}
+	if len(args) == 1 {
+		return args[0], "", true
+	}
+	sort.SliceStable(args, func(i, j int) bool {
+		return args[i].String() < args[j].String()
+	})
+	out := &RqlNode{Op: n.Op, Args: make([]interface{}, len(args)), Pos: n.Pos}
+	for i, a := range args {
+		out.Args[i] = a
+	}
+	return out, "", true
+}
+
+// predicates holds the comparisons of a single field in a conjunction.
+type predicates struct {
+	eqs, nes, lowers, uppers []*RqlNode
+}
+
+// mergeRanges merges the comparisons on the same field of a conjunction. It returns false,
+// and the field of the contradicting predicates if the conjunction can never be satisfied.
+func (nz normalizer) mergeRanges(args []*RqlNode) ([]*RqlNode, string, bool) {
+	var (
+		out    []*RqlNode
+		fields []string
+		groups = make(map[string]*predicates)
+	)
+	for _, a := range args {
+		if !isComparison(a) {
+			out = append(out, a)
+			continue
+		}
+		field := a.Args[0].(string)
+		g, ok := groups[field]
+		if !ok {
+			g = &predicates{}
+			groups[field] = g
+			fields = append(fields, field)
+		}
+		switch a.Op {
+		case "eq":
+			g.eqs = append(g.eqs, a)
+		case "ne":
+			g.nes = append(g.nes, a)
+		case "gt", "ge":
+			g.lowers = append(g.lowers, a)
+		case "lt", "le":
+			g.uppers = append(g.uppers, a)
+		}
+	}
+	for _, f := range fields {
+		merged, ok := groups[f].merge(nz.equalFunc(f))
+		if !ok {
+			return nil, f, false
+		}
+		out = append(out, merged...)
+	}
+	return out, "", true
+}
+
+// equalFunc returns the function that reports whether two values of the field are equal, and
+// whether the answer is known. Different strings are known to be unequal only for the string
+// fields of the model.
+func (nz normalizer) equalFunc(field string) func(a, b interface{}) (bool, bool) {
+	d, ok := containment{p: nz.p}.domain(field)
+	if ok && d.kind == stringDomain {
+		return typedEqual
+	}
+	return func(a, b interface{}) (bool, bool) {
+		x, okA := a.(string)
+		y, okB := b.(string)
+		if okA && okB && x != y {
+			return false, false
+		}
+		return typedEqual(a, b)
+	}
+}
+
+// merge returns the minimal set of predicates that is equivalent to the group,
+// or false if the predicates contradict each other.
+func (p *predicates) merge(equal func(a, b interface{}) (bool, bool)) ([]*RqlNode, bool) {
+	var out []*RqlNode
+	var eq *RqlNode
+	if len(p.eqs) > 0 {
+		eq = p.eqs[0]
+		out = append(out, eq)
+		for _, e := range p.eqs[1:] {
+			same, known := equal(eq.Args[1], e.Args[1])
+			if known && !same {
+				return nil, false
+			}
+			if !known {
+				out = append(out, e)
+			}
+		}
+	}
+	lowers, uppers := tightest(p.lowers, 1), tightest(p.uppers, -1)
+	if len(lowers) == 1 && len(uppers) == 1 {
+		lo, up := lowers[0], uppers[0]
+		if c, ok := typedCompare(lo.Args[1], up.Args[1]); ok {
+			if c > 0 || (c == 0 && (lo.Op == "gt" || up.Op == "lt")) {
+				return nil, false
+			}
+			if c == 0 && eq == nil {
+				eq = &RqlNode{Op: "eq", Args: lo.Args, Pos: lo.Pos}
+				out = append(out, eq)
+				lowers, uppers = nil, nil
+			}
+		}
+	}
+	if eq == nil {
+		out = append(out, lowers...)
+		out = append(out, uppers...)
+		return append(out, p.nes...), true
+	}
+	// the equality makes the bounds and the inequalities it satisfies redundant.
+	for _, b := range append(lowers, uppers...) {
+		sat, known := satisfies(eq.Args[1], b)
+		if known && !sat {
+			return nil, false
+		}
+		if !known {
+			out = append(out, b)
+		}
+	}
+	for _, ne := range p.nes {
+		same, known := equal(eq.Args[1], ne.Args[1])
+		if known && same {
+			return nil, false
+		}
+		if !known {
+			out = append(out, ne)
+		}
+	}
+	return out, true
+}
+
+// tightest returns the tightest bound of the given bounds. dir is 1 for lower bounds,
+// and -1 for upper bounds. If the bound values are not comparable, all of them are returned.
+func tightest(bounds []*RqlNode, dir int) []*RqlNode {
+	if len(bounds) < 2 {
+		return bounds
+	}
+	best := bounds[0]
+	for _, b := range bounds[1:] {
+		c, ok := typedCompare(b.Args[1], best.Args[1])
+		if !ok {
+			return bounds
+		}
+		if c*dir > 0 || (c == 0 && (b.Op == "gt" || b.Op == "lt")) {
+			best = b
+		}
+	}
+	return []*RqlNode{best}
+}
+
+// satisfies reports whether the value satisfies the bound, and whether the answer is known.
+func satisfies(v interface{}, bound *RqlNode) (bool, bool) {
+	c, ok := typedCompare(v, bound.Args[1])
+	if !ok {
+		return false, false
+	}
+	switch bound.Op {
+	case "gt":
+		return c > 0, true
+	case "ge":
+		return c >= 0, true
+	case "lt":
+		return c < 0, true
+	case "le":
+		return c <= 0, true
+	}
+	return false, false
+}
@@ -0,0 +1,229 @@
+package gorql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type NormalizeTest struct {
+	Name          string      // Name of the test
+	RQL           string      // Input RQL query
+	Model         interface{} // Input Model for query
+	Expected      string      // Expected RQL after normalization
+	Contradiction string      // Expected field of the contradiction, if any
+}
+
+// intModel makes the values of the fields a, b, c and d integers.
+var intModel = new(struct {
+	A, B, C, D int `rql:"filter"`
+})
+
+// stringModel has string fields, whose numeric values are compared as strings.
+var stringModel = new(struct {
+	Zip  string `rql:"filter"`
+	Name string `rql:"filter"`
+})
+
+var normalizeTests = []NormalizeTest{
+	{
+		Name:     `Flatten nested and`,
+		RQL:      `and(eq(a,1),and(eq(b,2),and(eq(c,3))))&eq(d,4)`,
+		Expected: `eq(a,1)&eq(b,2)&eq(c,3)&eq(d,4)`,
+	},
+	{
+		Name:     `Flatten nested or and sort arguments`,
+		RQL:      `or(eq(c,3),or(eq(b,2),eq(a,1)))`,
+		Expected: `or(eq(a,1),eq(b,2),eq(c,3))`,
+	},
+	{
+		Name:     `Remove duplicates`,
+		RQL:      `eq(a,1)&EQ(a,1)&or(eq(b,2),eq(b,2))`,
+		Expected: `eq(a,1)&eq(b,2)`,
+	},
+	{
+		Name:     `De Morgan`,
+		RQL:      `not(and(eq(a,1),or(lt(b,2),like(c,x*))))`,
+		Expected: `or(and(ge(b,2),not(like(c,x*))),ne(a,1))`,
+	},
+	{
+		Name:     `Double negation`,
+		RQL:      `not(not(eq(a,1)))`,
+		Expected: `eq(a,1)`,
+	},
+	{
+		Name:     `Not with multiple arguments`,
+		RQL:      `not(eq(a,1),gt(b,2))`,
+		Expected: `le(b,2)&ne(a,1)`,
+	},
+	{
+		Name:     `Not of a field is left as is`,
+		RQL:      `not(disabled)`,
+		Expected: `not(disabled)`,
+	},
+	{
+		Name:     `Merge bounds`,
+		RQL:      `ge(a,1)&gt(a,3)&ge(a,2)&le(a,10)&lt(a,10)`,
+		Model:    intModel,
+		Expected: `gt(a,3)&lt(a,10)`,
+	},
+	{
+		Name:     `Single value range`,
+		RQL:      `ge(a,5)&le(a,5)`,
+		Model:    intModel,
+		Expected: `eq(a,5)`,
+	},
+	{
+		Name:     `Equality makes bounds redundant`,
+		RQL:      `eq(a,5)&gt(a,1)&le(a,5)&ne(a,6)`,
+		Model:    intModel,
+		Expected: `eq(a,5)`,
+	},
+	{
+		Name:     `Bounds of strings are not merged`,
+		RQL:      `ge(a,x)&ge(a,y)`,
+		Expected: `ge(a,x)&ge(a,y)`,
+	},
+	{
+		Name:     `Contradicting branch of or is dropped`,
+		RQL:      `or(and(eq(a,1),eq(a,2)),eq(b,3))`,
+		Model:    intModel,
+		Expected: `eq(b,3)`,
+	},
+	{
+		Name:          `Contradicting equalities`,
+		RQL:           `eq(a,1)&eq(a,2)`,
+		Model:         intModel,
+		Contradiction: "a",
+	},
+	{
+		Name:          `Empty range`,
+		RQL:           `gt(a,5)&lt(a,3)`,
+		Model:         intModel,
+		Contradiction: "a",
+	},
+	{
+		Name:          `Open single value range`,
+		RQL:           `gt(a,5)&le(a,5)`,
+		Model:         intModel,
+		Contradiction: "a",
+	},
+	{
+		Name:          `Equality out of range`,
+		RQL:           `eq(a,10)&lt(a,3)`,
+		Model:         intModel,
+		Contradiction: "a",
+	},
+	{
+		Name:          `Equality and inequality`,
+		RQL:           `eq(a,x)&not(eq(a,x))`,
+		Contradiction: "a",
+	},
+	{
+		Name:     `Equal numbers of unknown type`,
+		RQL:      `eq(a,1)&eq(a,1.0)&eq(b,1)&eq(b,01)`,
+		Expected: `eq(a,1)&eq(a,1.0)&eq(b,01)&eq(b,1)`,
+	},
+	{
+		Name:     `Different strings of unknown type`,
+		RQL:      `eq(a,x)&ne(a,y)&eq(b,x)&eq(b,y)`,
+		Expected: `eq(a,x)&eq(b,x)&eq(b,y)&ne(a,y)`,
+	},
+	{
+		Name:     `Numeric strings are not merged`,
+		RQL:      `ge(a,1)&gt(a,3)&le(a,10)`,
+		Expected: `ge(a,1)&gt(a,3)&le(a,10)`,
+	},
+	{
+		Name:          `Numeric strings of a string field are not equal`,
+		RQL:           `eq(zip,01234)&eq(zip,1234)`,
+		Model:         stringModel,
+		Contradiction: "zip",
+	},
+	{
+		Name:     `Bounds of a string field are not ordered`,
+		RQL:      `gt(name,10)&lt(name,9)`,
+		Model:    stringModel,
+		Expected: `gt(name,10)&lt(name,9)`,
+	},
+	{
+		Name:     `Equality and inequality of a string field`,
+		RQL:      `eq(zip,1.0)&ne(zip,1)`,
+		Model:    stringModel,
+		Expected: `eq(zip,1.0)`,
+	},
+	{
+		Name: `Typed values`,
+		RQL:  `ge(createdAt,2020-01-01T00:00:00Z)&gt(createdAt,2021-01-01T00:00:00Z)&le(age,30)&le(age,40)`,
+		Model: new(struct {
+			Age       int       `rql:"filter"`
+			CreatedAt time.Time `rql:"filter"`
+		}),
+		Expected: `gt(createdAt,date:2021-01-01T00:00:00Z)&le(age,30)`,
+	},
+}
+
+func TestNormalize(t *testing.T) {
+	for _, test := range normalizeTests {
+		test.Run(t)
+	}
+}
+
+func (test NormalizeTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Parse error: %v", test.Name, err)
+	}
+	err = p.Normalize(root)
+	if test.Contradiction != "" {
+		var ce *ContradictionError
+		if !errors.As(err, &ce) || ce.Field != test.Contradiction {
+			t.Fatalf("(%s) Expecting contradiction on field %s, got: %v", test.Name, test.Contradiction, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("(%s) Normalize error: %v", test.Name, err)
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestNormalizeIdenticalQueries(t *testing.T) {
+	a := mustParse(t, `and(eq(b,2),eq(a,1))&or(eq(c,3),eq(d,4))`)
+	b := mustParse(t, `or(eq(d,4),eq(c,3))&eq(a,1)&eq(b,2)&eq(a,1)`)
+	if err := Normalize(a); err != nil {
+		t.Fatalf("Normalize error: %v", err)
+	}
+	if err := Normalize(b); err != nil {
+		t.Fatalf("Normalize error: %v", err)
+	}
+	if a.String() != b.String() {
+		t.Fatalf("Expecting identical output, got %s vs %s", a, b)
+	}
+}
+
+func TestNormalizeWithoutModel(t *testing.T) {
+	root := mustParse(t, `eq(a,1)&eq(a,01)`)
+	if err := Normalize(root); err != nil {
+		t.Fatalf("Expecting no contradiction for values of unknown type, got: %v", err)
+	}
+	if s := root.String(); s != `eq(a,01)&eq(a,1)` {
+		t.Fatalf("Expecting the equalities to be kept, got %s", s)
+	}
+	root = mustParse(t, `eq(a,1)&eq(a,1)&ne(a,1)`)
+	var ce *ContradictionError
+	if err := Normalize(root); !errors.As(err, &ce) || ce.Field != "a" {
+		t.Fatalf("Expecting a contradiction for identical values, got: %v", err)
+	}
+}
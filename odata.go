@@ -0,0 +1,341 @@
+package gorql
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// odataComparisons are the OData comparison operators that have the same name in RQL.
+var odataComparisons = map[string]bool{
+	"eq": true,
+	"ne": true,
+	"gt": true,
+	"ge": true,
+	"lt": true,
+	"le": true,
+}
+
+// odataFunctions maps the OData string functions to the wildcard pattern of the like operation.
+var odataFunctions = map[string]string{
+	"contains":   "*%s*",
+	"startswith": "%s*",
+	"endswith":   "*%s",
+}
+
+// ParseOData constructs an AST from the OData system query options. For example:
+//
+//	$filter=price gt 10 and (status in ('a','b') or contains(name,'foo'))&$orderby=price desc,name&$top=20&$skip=40
+//
+// The $filter comparisons eq, ne, gt, ge, lt and le, the logical operators and, or and not, and
+// the in operator are mapped to the RQL operations. The contains, startswith and endswith functions
+// are mapped to the like operation, e.g. contains(name,'foo') => like(name,*foo*), and their string
+// must not contain the '*' wildcard of the like operation. $orderby is mapped
+// to the sort, $select to the select, $top to the limit and $skip to the offset of the root node.
+// Other parameters are ignored.
+//
+// Literal values are strings like the values of Parse, and are converted to the field types when
+// the parser has a model. The result is validated with the configuration of the parser.
+func (p *Parser) ParseOData(q url.Values) (root *RqlRootNode, err error) {
+	root = &RqlRootNode{
+		limit:  q.Get("$top"),
+		offset: q.Get("$skip"),
+	}
+	if filter := q.Get("$filter"); strings.TrimSpace(filter) != "" {
+		if root.Node, err = p.parseODataFilter(filter); err != nil {
+			return nil, err
+		}
+	}
+	if root.sorts, err = parseODataOrderBy(q.Get("$orderby")); err != nil {
+		return nil, err
+	}
+	for _, s := range strings.Split(q.Get("$select"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			root.selects = append(root.selects, s)
+		}
+	}
+	if err = p.checkCost(context.Background(), root); err != nil {
+		setErrorQuery(err, q.Get("$filter"))
+		return nil, err
+	}
+	if err = p.validate(root); err != nil {
+		setErrorQuery(err, q.Get("$filter"))
+		return nil, err
+	}
+	return root, nil
+}
+
+// parseODataOrderBy parses the comma separated properties of $orderby, each optionally
+// followed by "asc" or "desc".
+func parseODataOrderBy(s string) (sorts []Sort, err error) {
+	offset := 0
+	for _, item := range strings.Split(s, ",") {
+		start := offset
+		offset += len(item) + 1
+		words := strings.Fields(item)
+		switch {
+		case len(words) == 0:
+			continue
+		case len(words) == 1:
+			sorts = append(sorts, Sort{By: words[0]})
+		case len(words) == 2 && (strings.EqualFold(words[1], "asc") || strings.EqualFold(words[1], "desc")):
+			sorts = append(sorts, Sort{By: words[0], Desc: strings.EqualFold(words[1], "desc")})
+		default:
+			return nil, &ParseError{
+				Query: s,
+				Pos:   Pos{Start: start, End: start + len(item)},
+				Err:   &SyntaxError{Msg: fmt.Sprintf("invalid $orderby item : %s", strings.TrimSpace(item))},
+			}
+		}
+	}
+	return sorts, nil
+}
+
+// parseODataFilter parses the $filter expression, and checks its complexity.
+func (p *Parser) parseODataFilter(filter string) (n *RqlNode, err error) {
+	defer func() {
+		setErrorQuery(err, filter)
+	}()
+	if err = p.checkLength(filter); err != nil {
+		return nil, err
+	}
+	op := &odataParser{p: p}
+	if op.tokens, err = scanOData(filter); err != nil {
+		return nil, err
+	}
+	if n, err = op.parseOr(); err != nil {
+		return nil, err
+	}
+	if t := op.peek(); t.t != Eof {
+		return nil, op.errorf(t, "unexpected token : %s", t.s)
+	}
+	if err = p.checkComplexity(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// scanOData splits the $filter expression into tokens. Words are scanned as identifiers,
+// and the quoted literals as strings, with the doubled single quotes unescaped.
+func scanOData(s string) (tokens []TokenString, err error) {
+	for i := 0; i < len(s); {
+		start := i
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+			continue
+		case c == '(':
+			i++
+			tokens = append(tokens, TokenString{t: OpeningParenthesis, s: "(", pos: Pos{Start: start, End: i}})
+		case c == ')':
+			i++
+			tokens = append(tokens, TokenString{t: ClosingParenthesis, s: ")", pos: Pos{Start: start, End: i}})
+		case c == ',':
+			i++
+			tokens = append(tokens, TokenString{t: Comma, s: ",", pos: Pos{Start: start, End: i}})
+		case c == '\'':
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, &ParseError{Pos: Pos{Start: start, End: i}, Err: &SyntaxError{Msg: fmt.Sprintf("unterminated string : %s", s[start:])}}
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+				b.WriteByte(s[i])
+			}
+			i++
+			tokens = append(tokens, TokenString{t: String, s: b.String(), pos: Pos{Start: start, End: i}})
+		default:
+			for i < len(s) && !strings.ContainsRune(" \t(),'", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, TokenString{t: Ident, s: s[start:i], pos: Pos{Start: start, End: i}})
+		}
+	}
+	return tokens, nil
+}
+
+// odataParser is a recursive descent parser of the OData $filter tokens.
+type odataParser struct {
+	tokens []TokenString
+	i      int
+	// p is the parser that holds the complexity limits, and depth is the nesting of
+	// parentheses and negations.
+	p     *Parser
+	depth int
+}
+
+// peek returns the next token without consuming it, or an Eof token at the end of the input.
+func (op *odataParser) peek() TokenString {
+	if op.i < len(op.tokens) {
+		return op.tokens[op.i]
+	}
+	end := 0
+	if len(op.tokens) > 0 {
+		end = op.tokens[len(op.tokens)-1].pos.End
+	}
+	return TokenString{t: Eof, pos: Pos{Start: end, End: end}}
+}
+
+func (op *odataParser) next() TokenString {
+	t := op.peek()
+	if t.t != Eof {
+		op.i++
+	}
+	return t
+}
+
+// isKeyword reports whether the token is the given keyword.
+func isKeyword(t TokenString, keyword string) bool {
+	return t.t == Ident && strings.EqualFold(t.s, keyword)
+}
+
+// parseOr parses the disjunction of conjunctions.
+func (op *odataParser) parseOr() (*RqlNode, error) {
+	return op.parseList("or", op.parseAnd)
+}
+
+// parseAnd parses the conjunction of unary expressions.
+func (op *odataParser) parseAnd() (*RqlNode, error) {
+	return op.parseList("and", op.parseUnary)
+}
+
+// parseList parses the operands separated by the keyword, and joins them with the keyword
+// operation if there are more than one.
+func (op *odataParser) parseList(keyword string, operand func() (*RqlNode, error)) (*RqlNode, error) {
+	n, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	args := []interface{}{n}
+	start := n.Pos.Start
+	for isKeyword(op.peek(), keyword) {
+		op.next()
+		if n, err = operand(); err != nil {
+			return nil, err
+		}
+		args = append(args, n)
+	}
+	if len(args) == 1 {
+		return n, nil
+	}
+	return &RqlNode{Op: keyword, Args: args, Pos: Pos{Start: start, End: n.Pos.End}}, nil
+}
+
+// parseUnary parses a negation, or a primary expression.
+func (op *odataParser) parseUnary() (*RqlNode, error) {
+	if t := op.peek(); isKeyword(t, "not") {
+		if err := op.enter(t); err != nil {
+			return nil, err
+		}
+		op.next()
+		n, err := op.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		op.depth--
+		return &RqlNode{Op: "not", Args: []interface{}{n}, Pos: Pos{Start: t.pos.Start, End: n.Pos.End}}, nil
+	}
+	return op.parsePrimary()
+}
+
+// enter increments the nesting depth at the token, and checks it against MaxDepth.
+func (op *odataParser) enter(t TokenString) error {
+	if op.depth++; op.p.exceeds(LimitDepth, op.depth) {
+		return op.p.complexityError(LimitDepth, op.depth, t.pos)
+	}
+	return nil
+}
+
+// parsePrimary parses a parenthesized expression, a function call or a comparison.
+func (op *odataParser) parsePrimary() (*RqlNode, error) {
+	t := op.next()
+	switch {
+	case t.t == OpeningParenthesis:
+		if err := op.enter(t); err != nil {
+			return nil, err
+		}
+		n, err := op.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := op.next(); c.t != ClosingParenthesis {
+			return nil, &ParseError{Pos: t.pos, Err: &SyntaxError{Msg: ErrParenthesisMalformed.Error(), Err: ErrParenthesisMalformed}}
+		}
+		op.depth--
+		return n, nil
+	case t.t != Ident:
+		return nil, op.errorf(t, "expecting a property or a function, got : %s", t.s)
+	}
+	if pattern, ok := odataFunctions[strings.ToLower(t.s)]; ok && op.peek().t == OpeningParenthesis {
+		args, end, err := op.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != 2 || args[0].t != Ident || args[1].t != String {
+			return nil, op.errorf(t, "%s expects a property and a string", t.s)
+		}
+		if strings.Contains(args[1].s, "*") {
+			// the like operation has no escape for its wildcard, so a literal '*' would match any text.
+			return nil, op.errorf(args[1], "%s does not support the '*' character", t.s)
+		}
+		n := &RqlNode{Op: "like", Args: []interface{}{args[0].s, fmt.Sprintf(pattern, args[1].s)}}
+		n.Pos = Pos{Start: t.pos.Start, End: end.pos.End}
+		return n, nil
+	}
+	o := op.next()
+	switch {
+	case isKeyword(o, "in"):
+		values, end, err := op.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		pos := Pos{Start: t.pos.Start, End: end.pos.End}
+		group := &RqlNode{Op: "group", Args: []interface{}{t.s}, Pos: pos}
+		for _, v := range values {
+			group.Args = append(group.Args, v.s)
+		}
+		return &RqlNode{Op: "in", Args: []interface{}{t.s, group}, Pos: pos}, nil
+	case o.t == Ident && odataComparisons[strings.ToLower(o.s)]:
+		v := op.next()
+		if v.t != Ident && v.t != String {
+			return nil, op.errorf(v, "expecting a value after %s %s", t.s, o.s)
+		}
+		return &RqlNode{Op: strings.ToLower(o.s), Args: []interface{}{t.s, v.s}, Pos: Pos{Start: t.pos.Start, End: v.pos.End}}, nil
+	}
+	return nil, op.errorf(o, "expecting a comparison operator after %s", t.s)
+}
+
+// parseValues parses a parenthesized, comma separated list of literals or properties,
+// and returns them with the closing parenthesis.
+func (op *odataParser) parseValues() (values []TokenString, end TokenString, err error) {
+	opening := op.next()
+	if opening.t != OpeningParenthesis {
+		return nil, end, op.errorf(opening, "expecting a list of values")
+	}
+	for {
+		v := op.next()
+		if v.t != Ident && v.t != String {
+			return nil, end, op.errorf(v, "expecting a value, got : %s", v.s)
+		}
+		values = append(values, v)
+		switch end = op.next(); end.t {
+		case Comma:
+		case ClosingParenthesis:
+			return values, end, nil
+		default:
+			return nil, end, &ParseError{Pos: opening.pos, Err: &SyntaxError{Msg: ErrParenthesisMalformed.Error(), Err: ErrParenthesisMalformed}}
+		}
+	}
+}
+
+// errorf returns a syntax error at the position of the token.
+func (op *odataParser) errorf(t TokenString, format string, args ...interface{}) error {
+	return &ParseError{Pos: t.pos, Err: &SyntaxError{Msg: fmt.Sprintf(format, args...)}}
+}
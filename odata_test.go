@@ -0,0 +1,180 @@
+package gorql
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func FuzzParseOData(f *testing.F) {
+	f.Add(`price gt 10 and (status in ('a','b') or contains(name,'foo'))`)
+	f.Fuzz(func(t *testing.T, a string) {
+		p, err := NewParser(nil)
+		if err != nil {
+			t.Fatalf("New parser error :%s", err)
+		}
+		_, _ = p.ParseOData(url.Values{"$filter": {a}})
+	})
+}
+
+type ODataTest struct {
+	Name     string      // Name of the test
+	Query    url.Values  // Input OData query options
+	Model    interface{} // Input Model for query
+	Expected string      // Expected canonical RQL
+	WantErr  bool        // Expected parse error
+}
+
+var odataTests = []ODataTest{
+	{
+		Name:     `Comparisons and logical operators`,
+		Query:    url.Values{"$filter": {`price gt 10 and (status eq 'a' or not (name ne 'O''Neil'))`}},
+		Expected: `gt(price,10)&or(eq(status,a),not(ne(name,"O'Neil")))`,
+	},
+	{
+		Name:     `Precedence of and over or`,
+		Query:    url.Values{"$filter": {`a eq 1 or b eq 2 and c le 3`}},
+		Expected: `or(eq(a,1),and(eq(b,2),le(c,3)))`,
+	},
+	{
+		Name:     `In operator`,
+		Query:    url.Values{"$filter": {`status in ('a', 'b c')`}},
+		Expected: `in(status,[a,"b c"])`,
+	},
+	{
+		Name:     `String functions`,
+		Query:    url.Values{"$filter": {`contains(name,'foo') and startswith(code,'A') and endswith(mail,'.org')`}},
+		Expected: `like(name,*foo*)&like(code,A*)&like(mail,*.org)`,
+	},
+	{
+		Name: `Paging, sort and select`,
+		Query: url.Values{
+			"$filter":  {`price lt 100`},
+			"$orderby": {`price desc, name asc,id`},
+			"$select":  {`name, price`},
+			"$top":     {`20`},
+			"$skip":    {`40`},
+			"$count":   {`true`},
+		},
+		Expected: `lt(price,100)&sort(-price,+name,+id)&select(name,price)&limit(20,40)`,
+	},
+	{
+		Name:     `No filter`,
+		Query:    url.Values{"$top": {`5`}},
+		Expected: `limit(5)`,
+	},
+	{
+		Name:  `Model validation`,
+		Query: url.Values{"$filter": {`age ge 18 and name eq 'foo'`}, "$orderby": {`age desc`}},
+		Model: new(struct {
+			Age  int    `rql:"filter,sort"`
+			Name string `rql:"filter"`
+		}),
+		Expected: `ge(age,18)&eq(name,foo)&sort(-age)`,
+	},
+	{
+		Name:  `Unknown field`,
+		Query: url.Values{"$filter": {`size eq 1`}},
+		Model: new(struct {
+			Age int `rql:"filter"`
+		}),
+		WantErr: true,
+	},
+	{
+		Name:    `Missing operator`,
+		Query:   url.Values{"$filter": {`price 10`}},
+		WantErr: true,
+	},
+	{
+		Name:    `Missing value`,
+		Query:   url.Values{"$filter": {`price eq`}},
+		WantErr: true,
+	},
+	{
+		Name:    `Unclosed parenthesis`,
+		Query:   url.Values{"$filter": {`(price eq 1 and a eq 2`}},
+		WantErr: true,
+	},
+	{
+		Name:    `Unterminated string`,
+		Query:   url.Values{"$filter": {`name eq 'foo`}},
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid function arguments`,
+		Query:   url.Values{"$filter": {`contains(name)`}},
+		WantErr: true,
+	},
+	{
+		Name:    `Wildcard in function string`,
+		Query:   url.Values{"$filter": {`contains(name,'50*off')`}},
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid orderby`,
+		Query:   url.Values{"$orderby": {`price sideways`}},
+		WantErr: true,
+	},
+	{
+		Name:    `Invalid top`,
+		Query:   url.Values{"$top": {`ten`}},
+		WantErr: true,
+	},
+}
+
+func TestParseOData(t *testing.T) {
+	for _, test := range odataTests {
+		test.Run(t)
+	}
+}
+
+func (test ODataTest) Run(t *testing.T) {
+	var c *Config
+	if test.Model != nil {
+		c = &Config{Model: test.Model}
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.ParseOData(test.Query)
+	if test.WantErr != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantErr, err)
+	}
+	if err != nil {
+		return
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestParseODataErrorPosition(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	_, err = p.ParseOData(url.Values{"$filter": {`price gt 10 and name foo 'x'`}})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code() != CodeSyntax {
+		t.Fatalf("Expecting a syntax error, got: %v", err)
+	}
+	if expected := "price gt 10 and name foo 'x'\n                     ^^^"; pe.Snippet() != expected {
+		t.Fatalf("Unexpected error snippet:\n%s", pe.Snippet())
+	}
+}
+
+func TestParseODataWildcardPosition(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	_, err = p.ParseOData(url.Values{"$filter": {`startswith(name,'a*')`}})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code() != CodeSyntax {
+		t.Fatalf("Expecting a syntax error, got: %v", err)
+	}
+	if expected := "startswith(name,'a*')\n                ^^^^"; pe.Snippet() != expected {
+		t.Fatalf("Unexpected error snippet:\n%s", pe.Snippet())
+	}
+}
@@ -2,12 +2,14 @@ package gorql
 
 import (
 	"container/list"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -22,6 +24,8 @@ const (
 type RqlNode struct {
 	Op   string
 	Args []interface{}
+	// Pos is the span of the query that the node was parsed from.
+	Pos Pos
 }
 
 type Sort struct {
@@ -53,14 +57,75 @@ func (r *RqlRootNode) Selects() []string {
 	return r.selects
 }
 
+// SetLimit sets the limit of the root node. It is not validated against the parser configuration.
+func (r *RqlRootNode) SetLimit(limit string) {
+	r.limit = limit
+}
+
+// SetOffset sets the offset of the root node. It is not validated against the parser configuration.
+func (r *RqlRootNode) SetOffset(offset string) {
+	r.offset = offset
+}
+
+// SetSort sets the sort fields of the root node. It is not validated against the parser configuration.
+func (r *RqlRootNode) SetSort(sorts []Sort) {
+	r.sorts = sorts
+}
+
+// SetSelects sets the selected fields of the root node. It is not validated against the parser configuration.
+func (r *RqlRootNode) SetSelects(selects []string) {
+	r.selects = selects
+}
+
+// Clone returns a deep copy of the root node, that does not share its nodes and slices with r.
+func (r *RqlRootNode) Clone() *RqlRootNode {
+	if r == nil {
+		return nil
+	}
+	return &RqlRootNode{
+		Node:    r.Node.Clone(),
+		limit:   r.limit,
+		offset:  r.offset,
+		selects: append([]string(nil), r.selects...),
+		sorts:   append([]Sort(nil), r.sorts...),
+	}
+}
+
+// Clone returns a deep copy of the node and its children. The values of the arguments
+// are shared, as they are never modified.
+func (n *RqlNode) Clone() *RqlNode {
+	if n == nil {
+		return nil
+	}
+	c := &RqlNode{Op: n.Op, Args: make([]interface{}, len(n.Args)), Pos: n.Pos}
+	for i, a := range n.Args {
+		if child, ok := a.(*RqlNode); ok {
+			a = child.Clone()
+		}
+		c.Args[i] = a
+	}
+	return c
+}
+
+// ErrBlocValue and ErrBlocBracket were returned by the former parser, that split the tokens
+// in blocs.
+//
+// Deprecated: the parser reads the tokens in a single pass, and does not return them.
+var (
+	ErrBlocValue   = errors.New("bloc is a value")
+	ErrBlocBracket = errors.New("bloc is a square bracket")
+)
+
 var (
-	ErrBlocValue                 = errors.New("bloc is a value")
-	ErrBlocBracket               = errors.New("bloc is a square bracket")
 	ErrParenthesisMalformed      = errors.New("parenthesis bloc is malformed")
 	ErrUnregonizedBloc           = errors.New("unrecognized bloc")
 	ErrInvalidPlacementSqrBrBloc = errors.New("invalid formation of square brackets bloc")
 )
 
+// TokenBloc is a sequence of tokens.
+//
+// Deprecated: the parser does not split the tokens in blocs since it parses them in a single
+// pass. TokenBloc is kept for compatibility.
 type TokenBloc []TokenString
 
 // String print the TokenBloc value for test purpose only
@@ -99,9 +164,11 @@ func parseLimitOffset(n *RqlNode, root *RqlRootNode) (isLimitOp bool) {
 		return false
 	}
 	if strings.ToLower(n.Op) == LimitOp {
-		root.limit = n.Args[0].(string)
+		if len(n.Args) > 0 {
+			root.limit = formatValue(n.Args[0])
+		}
 		if len(n.Args) > 1 {
-			root.offset = n.Args[1].(string)
+			root.offset = formatValue(n.Args[1])
 		}
 		isLimitOp = true
 	}
@@ -113,7 +180,9 @@ func parseOffset(n *RqlNode, root *RqlRootNode) (isOffsetOp bool) {
 		return false
 	}
 	if strings.ToLower(n.Op) == OffsetOp {
-		root.offset = n.Args[0].(string)
+		if len(n.Args) > 0 {
+			root.offset = formatValue(n.Args[0])
+		}
 		isOffsetOp = true
 	}
 	return
@@ -125,10 +194,12 @@ func parseSort(n *RqlNode, root *RqlRootNode) (isSortOp bool) {
 	}
 	if n.Op == SortOp {
 		for _, s := range n.Args {
-			property := s.(string)
+			property := formatValue(s)
 			desc := false
 
-			if property[0] == '+' {
+			if property == "" {
+				continue
+			} else if property[0] == '+' {
 				property = property[1:]
 			} else if property[0] == '-' {
 				desc = true
@@ -148,7 +219,7 @@ func parseFields(n *RqlNode, root *RqlRootNode) (isFieldsOp bool) {
 	}
 	if n.Op == SelectOp {
 		for _, s := range n.Args {
-			property := s.(string)
+			property := formatValue(s)
 			root.selects = append(root.selects, property)
 		}
 		isFieldsOp = true
@@ -156,10 +227,14 @@ func parseFields(n *RqlNode, root *RqlRootNode) (isFieldsOp bool) {
 	return
 }
 
+// Parser is safe for concurrent use by multiple goroutines. Its configuration
+// and field rules are fixed once NewParser returns, and every call to Parse
+// uses its own scanner state.
 type Parser struct {
-	s      *Scanner
 	c      *Config
 	fields map[string]*field
+	// cache holds the validated roots of the recent queries, if Config.CacheSize is set.
+	cache *parseCache
 }
 
 // field is a configuration of a struct field.
@@ -176,16 +251,27 @@ type field struct {
 	ValidateFn func(interface{}) error
 	// ConvertFn converts the given value to the type value.
 	CovertFn func(interface{}) (interface{}, error)
-}
-
+	// Type of the struct field, with its pointers indirected.
+	Type reflect.Type
+	// Cost is the cost weight of the field, set by the "cost" option in the tag. Zero means that
+	// the option is not set.
+	Cost int
+	// Index is the index sequence of the field in the model, for reflect.Value.FieldByIndex.
+	Index []int
+}
+
+// NewParser returns a parser configured by c. The parser keeps a copy of c, with the defaults of
+// the unset options, like DefaultLimit and LimitMaxValue. The defaults are not written back to c,
+// so the zero options of c stay zero after NewParser returns.
 func NewParser(c *Config) (*Parser, error) {
 	p := &Parser{
-		s:      NewScanner(),
-		c:      c,
 		fields: make(map[string]*field),
 	}
 	if c != nil {
-		err := c.defaults()
+		// keep a private copy, so changes made by the caller after the parser
+		// was built can not race with the parsing goroutines.
+		p.c = c.clone()
+		err := p.c.defaults()
 		if err != nil {
 			return nil, err
 		}
@@ -193,10 +279,26 @@ func NewParser(c *Config) (*Parser, error) {
 		if err != nil {
 			return nil, err
 		}
+		if p.c.CacheSize > 0 {
+			p.cache = newParseCache(p.c.CacheSize, p.c.CacheTTL)
+		}
 	}
 	return p, nil
 }
 
+// lookupField returns the field of the given name, or of the name that replaces it after the validation.
+func (p *Parser) lookupField(name string) (*field, bool) {
+	if f, ok := p.fields[name]; ok {
+		return f, true
+	}
+	for _, f := range p.fields {
+		if f.ReplaceWith == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
 // init initializes the parser parsing state. it scans the fields
 // in a breath-first-search order and for each one of the field calls parseField.
 func (p *Parser) init() error {
@@ -221,6 +323,7 @@ func (p *Parser) init() error {
 				if !f.Anonymous {
 					structField.Name = f.Name + p.c.FieldSep + structField.Name
 				}
+				structField.Index = append(append([]int(nil), f.Index...), structField.Index...)
 				l.PushFront(structField)
 			}
 		case f.Anonymous:
@@ -236,6 +339,8 @@ func (p *Parser) parseField(sf reflect.StructField) error {
 	f := &field{
 		Name:     p.c.ColumnFn(sf.Name),
 		CovertFn: valueFn,
+		Type:     indirect(sf.Type),
+		Index:    sf.Index,
 	}
 	layout := time.RFC3339
 	opts := strings.Split(sf.Tag.Get(p.c.TagName), ",")
@@ -249,6 +354,13 @@ func (p *Parser) parseField(sf reflect.StructField) error {
 			f.Name = strings.TrimPrefix(opt, "column=")
 		case strings.HasPrefix(opt, "replacewith"):
 			f.ReplaceWith = strings.TrimPrefix(opt, "replacewith=")
+		case strings.HasPrefix(opt, "cost"):
+			cost, err := strconv.Atoi(strings.TrimPrefix(opt, "cost="))
+			// a zero cost would be taken as an unset option, and the field would cost DefaultFieldCost.
+			if err != nil || cost < 1 {
+				return fmt.Errorf("rql: cost %q of field %q is not valid", opt, sf.Name)
+			}
+			f.Cost = cost
 		case strings.HasPrefix(opt, "layout"):
 			layout = strings.TrimPrefix(opt, "layout=")
 			// if it's one of the standard layouts, like: RFC822 or Kitchen.
@@ -320,26 +432,56 @@ func (p *Parser) parseField(sf reflect.StructField) error {
 
 // Parse constructs an AST for code transformation
 func (p *Parser) Parse(r io.Reader) (root *RqlRootNode, err error) {
+	return p.ParseContext(context.Background(), r)
+}
+
+// ParseContext is like Parse, and rejects the queries whose cost exceeds the budget that was
+// set on ctx with WithCostBudget. For example:
+//
+//	ctx := gorql.WithCostBudget(r.Context(), 50)
+//	root, err := parser.ParseContext(ctx, strings.NewReader(r.URL.RawQuery))
+func (p *Parser) ParseContext(ctx context.Context, r io.Reader) (root *RqlRootNode, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	query := string(b)
+	defer func() {
+		setErrorQuery(err, query)
+	}()
+	if cached, cost, ok := p.cache.get(query); ok {
+		if err = p.checkBudget(ctx, cached, cost); err != nil {
+			return nil, err
+		}
+		return cached, nil
+	}
+	if err = p.checkLength(query); err != nil {
+		return nil, err
+	}
 	var tokenStrings []TokenString
-	if tokenStrings, err = p.s.Scan(r); err != nil {
+	if tokenStrings, err = NewScanner().Scan(strings.NewReader(query)); err != nil {
+		return nil, err
+	}
+	if err = p.checkTokenDepth(tokenStrings); err != nil {
 		return nil, err
 	}
 	root = &RqlRootNode{}
 	root.Node, err = parse(tokenStrings)
 	if err != nil {
-		return nil, err
+		return nil, syntaxError(err)
 	}
 	root.parseSpecialOps()
-	err = p.validateSpecialOps(root)
-	if err != nil {
+	if err = p.checkComplexity(root.Node); err != nil {
 		return nil, err
 	}
-	if p.c != nil {
-		err := p.validateFields(root.Node)
-		if err != nil {
-			return nil, err
-		}
+	cost := p.Cost(root)
+	if err = p.checkBudget(ctx, root, cost); err != nil {
+		return nil, err
+	}
+	if err = p.validate(root); err != nil {
+		return nil, err
 	}
+	p.cache.add(query, root, cost)
 	return
 }
 
@@ -348,336 +490,301 @@ func (p *Parser) ParseURL(q url.Values) (root *RqlRootNode, err error) {
 	rqlQuery, _ := url.PathUnescape(encodeURLValues(q))
 	root, err = p.Parse(strings.NewReader(rqlQuery))
 	if err != nil {
-		return nil, fmt.Errorf("url parse error: %s", err)
+		return nil, fmt.Errorf("url parse error: %w", err)
 	}
 	return root, nil
 }
 
-// parse recursively return the children node from the tokens
-func parse(ts []TokenString) (node *RqlNode, err error) {
-	var childNode *RqlNode
-
-	var childTs [][]TokenString
-	node = &RqlNode{}
-	if len(ts) == 0 {
-		return nil, nil
-	}
-	if isParenthesisBloc(ts) && findClosingIndex(ts[1:]) == len(ts)-2 {
-		if len(ts)-2 < 0 {
-			return nil, ErrParenthesisMalformed
-		}
-		ts = ts[1 : len(ts)-1]
-	}
-
-	node.Op, childTs = splitByBasisOp(ts)
-	if node.Op == "" || len(childTs) == 1 {
-		return getBlocNode(ts)
+// parse returns the node tree of the tokens of a query, or nil if there are no tokens.
+//
+// The tokens are parsed by a recursive descent parser, that visits each token once. The
+// operations joined with '&' are wrapped in an AND node, and the operations joined with
+// '|' or ';' in an OR node, which binds tighter than '&'.
+func parse(ts []TokenString) (*RqlNode, error) {
+	tp := &tokenParser{ts: ts}
+	n, err := tp.parseAnd(false)
+	if err != nil {
+		return nil, err
 	}
-
-	for _, c := range childTs {
-		childNode, err = parse(c)
-		if err != nil {
-			if isSingleBlocError(err) {
-				node.Args = append(node.Args, c[0].s)
-			} else {
-				return nil, err
-			}
-		} else {
-			node.Args = append(node.Args, childNode)
-		}
+	if tp.i < len(tp.ts) {
+		return nil, tp.unexpected()
 	}
-
-	return
+	return n, nil
 }
 
-// isParenthesisBloc returns true if the token strings is a parenthesis block
-func isParenthesisBloc(tb []TokenString) bool {
-	return tb[0].t == OpeningParenthesis
+// tokenParser holds the state of the parsing of the tokens of a query.
+type tokenParser struct {
+	ts []TokenString
+	// i is the index of the next token.
+	i int
+	// list is the last AND or OR node that was created, whose position is extended to
+	// the parentheses that enclose it.
+	list *RqlNode
 }
 
-// findClosingIndex returns the index of the closing parenthesis from the token strings
-func findClosingIndex(tb []TokenString) int {
-	i := findTokenIndex(tb, ClosingParenthesis)
-	return i
+// peek returns the type of the token at offset k of the next token, or Eof.
+func (tp *tokenParser) peek(k int) Token {
+	if tp.i+k < len(tp.ts) {
+		return tp.ts[tp.i+k].t
+	}
+	return Eof
 }
 
-// findTokenIndex returns the token index for the search token
-func findTokenIndex(tb []TokenString, token Token) int {
-	depth := 0
-	for i, ts := range tb {
-		if ts.t == OpeningParenthesis {
-			depth++
-		} else if ts.t == ClosingParenthesis {
-			if depth == 0 && token == ClosingParenthesis {
-				return i
-			}
-			depth--
-		} else if token == ts.t && depth == 0 {
-			return i
+// parseAnd parses the operands joined with '&'. Empty operands are ignored, as in a=1&&b=2.
+// In the arguments of an operation, a comma ends the operands.
+func (tp *tokenParser) parseAnd(inArgs bool) (*RqlNode, error) {
+	start := tp.i
+	var args []interface{}
+	for {
+		n, err := tp.parseOr(inArgs)
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			args = append(args, n)
 		}
+		if tp.peek(0) != Ampersand {
+			return tp.join("AND", start, args), nil
+		}
+		tp.i++
 	}
-	return -1
 }
 
-func splitByBasisOp(tb []TokenString) (op string, tbs [][]TokenString) {
-	matchingToken := Illegal
-
-	depth := 0
-	lastIndex := 0
-
-	basisTokenGroups := [][]Token{
-		{Ampersand},
-		{Pipe, SemiColon},
-	}
-	for _, bt := range basisTokenGroups {
-		btExtended := append(bt, Illegal)
-		for i, ts := range tb {
-			if ts.t == OpeningParenthesis {
-				depth++
-			} else if ts.t == ClosingParenthesis && depth > 0 {
-				depth--
-			} else if depth == 0 {
-				if isTokenInSlice(bt, ts.t) && isTokenInSlice(btExtended, matchingToken) {
-					matchingToken = ts.t
-					tbs = append(tbs, tb[lastIndex:i])
-					lastIndex = i + 1
-				}
-			}
+// parseOr parses the operands joined with '|' or ';'.
+func (tp *tokenParser) parseOr(inArgs bool) (*RqlNode, error) {
+	start := tp.i
+	var args []interface{}
+	for {
+		n, err := tp.parseOperand(inArgs)
+		if err != nil {
+			return nil, err
 		}
-		if lastIndex != 0 {
-			break
+		if n != nil {
+			args = append(args, n)
 		}
+		if t := tp.peek(0); t != Pipe && t != SemiColon {
+			return tp.join("OR", start, args), nil
+		}
+		tp.i++
 	}
-
-	tbs = append(tbs, tb[lastIndex:])
-	op = getTokenOp(matchingToken)
-	return
 }
 
-func isTokenInSlice(tokens []Token, tok Token) bool {
-	for _, t := range tokens {
-		if t == tok {
-			return true
-		}
+// join returns the node of the operands that were parsed from the token start,
+// or the operand itself if there is only one.
+func (tp *tokenParser) join(op string, start int, args []interface{}) *RqlNode {
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0].(*RqlNode)
 	}
-	return false
+	tp.list = &RqlNode{Op: op, Args: args, Pos: blocPos(tp.ts[start:tp.i])}
+	return tp.list
 }
 
-func getTokenOp(t Token) string {
-	switch t {
-	case Ampersand:
-		return "AND"
-	case Pipe, SemiColon:
-		return "OR"
+// atOperandEnd reports whether the next token ends an operand.
+func (tp *tokenParser) atOperandEnd(inArgs bool) bool {
+	switch tp.peek(0) {
+	case Eof, Ampersand, Pipe, SemiColon, ClosingParenthesis:
+		return true
+	case Comma:
+		return inArgs
 	}
-	return ""
+	return false
 }
 
-func getBlocNode(tb []TokenString) (*RqlNode, error) {
-	n := &RqlNode{}
-
-	if len(tb) < 1 {
-		return nil, fmt.Errorf("%s: %s", ErrUnregonizedBloc, TokenBloc(tb).String())
+// parseOperand parses an operation, or returns nil for an empty operand.
+func (tp *tokenParser) parseOperand(inArgs bool) (*RqlNode, error) {
+	if tp.atOperandEnd(inArgs) {
+		return nil, nil
+	}
+	n, err := tp.parseOperation(inArgs)
+	if err != nil {
+		return nil, err
+	}
+	if !tp.atOperandEnd(inArgs) {
+		return nil, tp.unexpected()
 	}
+	return n, nil
+}
 
-	if isValue(tb) {
-		return nil, ErrBlocValue
-	} else if isSqrBrStyleBloc(tb) {
-		var err error
-		n.Op = "group"
-		n.Args, err = parseArrArgs(tb)
+// parseOperation parses a parenthesized expression, or an operation in one of the forms:
+// op(args...), field=value,... or field,[values...].
+func (tp *tokenParser) parseOperation(inArgs bool) (*RqlNode, error) {
+	t := tp.ts[tp.i]
+	switch {
+	case t.t == OpeningParenthesis:
+		tp.i++
+		n, err := tp.parseAnd(false)
 		if err != nil {
 			return nil, err
 		}
-	} else if isFuncStyleBloc(tb) {
-		var err error
-		n.Op = tb[0].s
-		tb = tb[2:]
-		ci := findClosingIndex(tb)
-		if ci < 0 {
-			return nil, ErrParenthesisMalformed
+		if tp.peek(0) != ClosingParenthesis {
+			if tp.peek(0) == Eof {
+				return nil, errorAt([]TokenString{t}, ErrParenthesisMalformed)
+			}
+			return nil, tp.unexpected()
 		}
-		if len(tb) > ci+1 && tb[ci+1].t != ClosingParenthesis && tb[ci+1].t != Comma {
-			return nil, fmt.Errorf("unrecognized func style bloc (missing comma?)")
+		tp.i++
+		if n == nil {
+			return nil, errorAt([]TokenString{t, tp.ts[tp.i-1]}, fmt.Errorf("%w : empty parentheses", ErrUnregonizedBloc))
 		}
-		n.Args, err = parseFuncArgs(tb[:ci])
+		if n == tp.list {
+			n.Pos.Start, n.Pos.End = t.pos.Start, tp.ts[tp.i-1].pos.End
+		}
+		return n, nil
+	case t.t == Ident && tp.peek(1) == OpeningParenthesis:
+		n := &RqlNode{Op: t.s}
+		tp.i += 2
+		args, err := tp.parseArgs(tp.ts[tp.i-1])
 		if err != nil {
 			return nil, err
 		}
-	} else if isSimpleEqualBloc(tb) {
-		n.Op = "eq"
-		field := tb[0].s
-		if strings.HasPrefix(field, "$") {
-			n.Op = strings.TrimPrefix(field, "$")
-		} else {
-			n.Args = []interface{}{field}
-		}
-		var values []string
-		if len(tb) > 2 {
-			for _, v := range tb[2:] {
-				values = append(values, v.s)
-			}
+		n.Args = args
+		n.Pos = Pos{Start: t.pos.Start, End: tp.ts[tp.i-1].pos.End}
+		return n, nil
+	case t.t == Ident && tp.peek(1) == EqualSign:
+		return tp.parseEqual(inArgs)
+	case t.t == Ident && !inArgs && tp.peek(1) == Comma && tp.peek(2) == OpeningSquareBracket:
+		return tp.parseGroup()
+	case isValueToken(t.t):
+		return nil, errorAt([]TokenString{t}, fmt.Errorf("%w : value %s is not an operation", ErrUnregonizedBloc, t.s))
+	}
+	return nil, tp.unexpected()
+}
+
+// parseArgs parses the arguments of an operation up to its closing parenthesis. The arguments
+// are values or operations, and an empty argument is an empty string. The arguments of the
+// form field,[values...] are the field and a group node of the field and its values.
+func (tp *tokenParser) parseArgs(opening TokenString) (args []interface{}, err error) {
+	if tp.peek(0) == ClosingParenthesis {
+		tp.i++
+		return nil, nil
+	}
+	if tp.peek(0) == Ident && tp.peek(1) == Comma && tp.peek(2) == OpeningSquareBracket {
+		field, err := tokenValue(tp.ts[tp.i])
+		if err != nil {
+			return nil, err
 		}
-		for i, v := range values {
-			if i%2 == 0 {
-				n.Args = append(n.Args, v)
-			}
+		group, err := tp.parseGroup()
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		return nil, fmt.Errorf("%s : %s", ErrUnregonizedBloc, TokenBloc(tb).String())
+		return []interface{}{field, group}, tp.closeArgs(opening)
 	}
-
-	return n, nil
-}
-
-func isValue(tb []TokenString) bool {
-	return len(tb) == 1 && tb[0].t == Ident
-}
-
-func isFuncStyleBloc(tb []TokenString) bool {
-	return (tb[0].t == Ident) && (tb[1].t == OpeningParenthesis)
-}
-
-func isSqrBrStyleBloc(tb []TokenString) bool {
-	return len(tb) >= 3 && tb[0].t == Ident && (tb[1].t == Comma) && (tb[2].t == OpeningSquareBracket)
-}
-
-func parseFuncArgs(tb []TokenString) (args []interface{}, err error) {
-	var argTokens [][]TokenString
-
-	if len(tb) > 3 && isSqrBrStyleBloc(tb) {
-		argTokens = append(argTokens, []TokenString{tb[0]})
-		argTokens = append(argTokens, tb)
-	} else {
-		commaIdxs := findAllTokenIndexes(tb, Comma)
-		if len(commaIdxs) == 0 {
-			argTokens = append(argTokens, tb)
-		} else {
-			lastIndex := 0
-			for _, commaIdx := range commaIdxs {
-				subTs := tb[lastIndex:commaIdx]
-				argTokens = append(argTokens, subTs)
-				lastIndex = commaIdx + 1
+	for {
+		var arg interface{}
+		switch t := tp.peek(0); {
+		case t == Comma || t == ClosingParenthesis:
+			arg = ""
+		case isValueToken(t) && (tp.peek(1) == Comma || tp.peek(1) == ClosingParenthesis):
+			if arg, err = tokenValue(tp.ts[tp.i]); err != nil {
+				return nil, err
+			}
+			tp.i++
+		default:
+			n, err := tp.parseAnd(true)
+			if err != nil {
+				return nil, err
 			}
-			subTs := tb[lastIndex:]
-			if len(subTs) == 0 {
-				subTs = append(subTs, TokenString{
-					t: Ident,
-					s: "",
-				})
+			if arg = n; n == nil {
+				arg = ""
 			}
-			argTokens = append(argTokens, subTs)
 		}
-	}
-
-	for _, ts := range argTokens {
-		n, err := parse(ts)
-		if err != nil {
-			if isSingleBlocError(err) {
-				args = append(args, ts[0].s)
-			} else {
-				return args, err
-			}
-		} else {
-			args = append(args, n)
+		args = append(args, arg)
+		if tp.peek(0) != Comma {
+			return args, tp.closeArgs(opening)
 		}
+		tp.i++
 	}
-
-	return
-}
-
-func isSingleBlocError(err error) bool {
-	return errors.Is(err, ErrBlocValue) || errors.Is(err, ErrBlocBracket)
 }
 
-func findAllTokenIndexes(tb []TokenString, token Token) (indexes []int) {
-	depth := 0
-	for i, ts := range tb {
-		if ts.t == OpeningParenthesis {
-			depth++
-		} else if ts.t == ClosingParenthesis {
-			if depth == 0 && token == ClosingParenthesis {
-				indexes = append(indexes, i)
-			}
-			depth--
-		} else if token == ts.t && depth == 0 {
-			indexes = append(indexes, i)
-		}
+// closeArgs consumes the closing parenthesis of the arguments of an operation.
+func (tp *tokenParser) closeArgs(opening TokenString) error {
+	switch tp.peek(0) {
+	case ClosingParenthesis:
+		tp.i++
+		return nil
+	case Eof:
+		return errorAt([]TokenString{opening}, ErrParenthesisMalformed)
 	}
-	return
+	return tp.unexpected()
 }
 
-func isSimpleEqualBloc(tb []TokenString) bool {
-	if len(tb) == 0 {
-		return false
+// parseEqual parses the field=value,... form of an operation, which is the eq operation on the
+// field and the values, or the operation named after the field if it starts with '$', as in
+// $limit=10. In the arguments of an operation, the commas separate the arguments, not the values.
+func (tp *tokenParser) parseEqual(inArgs bool) (*RqlNode, error) {
+	field := tp.ts[tp.i]
+	n := &RqlNode{Op: "eq", Args: []interface{}{field.s}}
+	if strings.HasPrefix(field.s, "$") {
+		n.Op, n.Args = field.s[1:], nil
+		if n.Op == "" {
+			return nil, errorAt(tp.ts[tp.i:tp.i+2], fmt.Errorf("%w : %s", ErrUnregonizedBloc, field.s))
+		}
 	}
-	isSimple := tb[0].t == Ident && tb[1].t == EqualSign
-	if len(tb) > 3 {
-		isSimple = isSimple && tb[3].t != EqualSign
+	tp.i += 2
+	for isValueToken(tp.peek(0)) {
+		v, err := tokenValue(tp.ts[tp.i])
+		if err != nil {
+			return nil, err
+		}
+		n.Args = append(n.Args, v)
+		tp.i++
+		if inArgs || tp.peek(0) != Comma || !isValueToken(tp.peek(1)) {
+			break
+		}
+		tp.i++
 	}
-
-	return isSimple
+	n.Pos = Pos{Start: field.pos.Start, End: tp.ts[tp.i-1].pos.End}
+	return n, nil
 }
 
-func parseArrArgs(tb []TokenString) (args []interface{}, err error) {
-	if len(tb) < 1 {
-		return nil, fmt.Errorf("empty token strings for array arguments")
+// parseGroup parses the field,[values...] form of a list of values, into a group node of the
+// field and its values. The empty values are ignored, except the last one.
+func (tp *tokenParser) parseGroup() (*RqlNode, error) {
+	field, opening := tp.ts[tp.i], tp.ts[tp.i+2]
+	n := &RqlNode{Op: "group"}
+	v, err := tokenValue(field)
+	if err != nil {
+		return nil, err
 	}
-	stack := make([]TokenString, 0)
-	count := 0
-
-	subTs := tb[:2]
-	arraySubTs := tb[2:]
-	for i, b := range arraySubTs {
-		if i == 0 && b.t != OpeningSquareBracket {
-			return nil, fmt.Errorf("second argument needs to be a single pair of square bracket array")
-		}
-		if i == len(arraySubTs)-1 && b.t != ClosingSquareBracket {
-			return nil, fmt.Errorf("last value is not enclosed with closing square bracket")
-		}
-
-		if b.t == OpeningSquareBracket {
-			stack = append(stack, b)
-		} else if b.t == ClosingSquareBracket {
-			if len(stack) > 0 && stack[len(stack)-1].t == OpeningSquareBracket {
-				stack = stack[:len(stack)-1]
-				count += 1
-			} else {
-				stack = append(stack, b)
+	n.Args = append(n.Args, v)
+	tp.i += 3
+	empty := true
+	for {
+		t := tp.peek(0)
+		switch {
+		case isValueToken(t) && empty:
+			v, err := tokenValue(tp.ts[tp.i])
+			if err != nil {
+				return nil, err
 			}
-		} else {
-			subTs = append(subTs, b)
+			n.Args = append(n.Args, v)
+			empty = false
+		case t == Comma:
+			empty = true
+		case t == ClosingSquareBracket:
+			if empty {
+				n.Args = append(n.Args, "")
+			}
+			tp.i++
+			n.Pos = Pos{Start: field.pos.Start, End: tp.ts[tp.i-1].pos.End}
+			return n, nil
+		case t == Eof || t == OpeningSquareBracket || t == ClosingParenthesis:
+			return nil, errorAt([]TokenString{opening}, ErrInvalidPlacementSqrBrBloc)
+		default:
+			return nil, tp.unexpected()
 		}
+		tp.i++
 	}
-	if count != 1 {
-		return nil, fmt.Errorf("array values need to be enclosed in a single pair square brackets")
-	}
-	if len(stack) != 0 {
-		return nil, ErrInvalidPlacementSqrBrBloc
-	}
+}
 
-	commaIdxs := findAllTokenIndexes(subTs, Comma)
-	if len(commaIdxs) == 0 {
-		args = append(args, subTs)
-	} else {
-		lastIndex := 0
-		for _, commaIdx := range commaIdxs {
-			subCommaTs := subTs[lastIndex:commaIdx]
-			for _, sct := range subCommaTs {
-				args = append(args, sct.s)
-			}
-			lastIndex = commaIdx + 1
-		}
-		subCommaTs := subTs[lastIndex:]
-		if len(subCommaTs) == 0 {
-			subCommaTs = append(subCommaTs, TokenString{
-				t: Ident,
-				s: "",
-			})
-		}
-		for _, sct := range subCommaTs {
-			args = append(args, sct.s)
-		}
+// unexpected returns the error of the next token, that is not valid at its position.
+func (tp *tokenParser) unexpected() error {
+	t := tp.ts[tp.i]
+	if t.t == ClosingParenthesis {
+		return errorAt([]TokenString{t}, ErrParenthesisMalformed)
 	}
-	return
+	return errorAt([]TokenString{t}, fmt.Errorf("unexpected token : %s", t.s))
 }
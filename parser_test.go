@@ -2,9 +2,12 @@ package gorql
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -367,3 +370,208 @@ func (p ParseFieldTest) Run(t *testing.T) {
 		t.Fatalf("(%s) Expecting error: %v, got: %v", p.Name, p.WantError, err)
 	}
 }
+
+func TestParseConcurrent(t *testing.T) {
+	p, err := NewParser(&Config{Model: new(struct {
+		Foo   string  `rql:"filter,sort"`
+		Price float64 `rql:"filter,sort"`
+	})})
+	if err != nil {
+		t.Fatalf("New parser error: %v", err)
+	}
+	queries := []string{
+		`and(eq(foo,42),gt(price,10))&sort(+price)&limit(10,20)`,
+		`or(eq(foo,john%20wick),lt(price,5))&select(foo)`,
+		`foo=bar&price=10&sort(-foo)`,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				q := queries[(i+j)%len(queries)]
+				if _, err := p.Parse(strings.NewReader(q)); err != nil {
+					t.Errorf("Parse(%q) error: %v", q, err)
+					return
+				}
+				if _, err := p.ParseURL(url.Values{"eq(foo,bar)": nil}); err != nil {
+					t.Errorf("ParseURL error: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNewParserCopiesConfig(t *testing.T) {
+	c := &Config{Model: new(struct {
+		Foo string `rql:"filter"`
+	})}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("New parser error: %v", err)
+	}
+	if c.DefaultLimit != 0 || c.LimitMaxValue != 0 || c.TagName != "" || c.FieldSep != "" || c.WildcardCost != 0 {
+		t.Fatalf("NewParser must not write the defaults back to the caller's config: %+v", c)
+	}
+	if p.c.DefaultLimit != DefaultLimit || p.c.LimitMaxValue != DefaultMaxLimit {
+		t.Fatalf("Expecting the parser to use the default limits, got: %+v", p.c)
+	}
+	c.LimitMaxValue = 1
+	if _, err := p.Parse(strings.NewReader(`limit(50)`)); err != nil {
+		t.Fatalf("parser must not observe later config changes: %v", err)
+	}
+}
+
+func TestNewParserCopiesConfigMaps(t *testing.T) {
+	c := &Config{
+		Model: new(struct {
+			Foo string `rql:"filter"`
+		}),
+		OpCosts:       map[string]int{"eq": 1},
+		FieldCosts:    map[string]int{"foo": 1},
+		Phrases:       map[string]string{"eq": "{field} equals {value}"},
+		IgnoredParams: []string{"api_key"},
+	}
+	p, err := NewParser(c)
+	if err != nil {
+		t.Fatalf("New parser error: %v", err)
+	}
+	c.OpCosts["eq"] = 100
+	c.FieldCosts["foo"] = 100
+	c.Phrases["eq"] = "changed"
+	c.IgnoredParams[0] = "foo"
+	if p.c.OpCosts["eq"] != 1 || p.c.FieldCosts["foo"] != 1 || p.c.Phrases["eq"] != "{field} equals {value}" || p.c.IgnoredParams[0] != "api_key" {
+		t.Fatalf("parser must not share the maps and slices of the caller's config: %+v", p.c)
+	}
+}
+
+type ParseSyntaxTest struct {
+	Name     string // Name of the test
+	RQL      string // Input RQL query
+	Expected string // Expected canonical RQL
+	WantErr  bool   // Expected syntax error
+}
+
+// parseSyntaxTests holds the queries whose parsing changed with the single pass parser:
+// the malformed queries that were misread are rejected, and redundant parentheses are accepted.
+var parseSyntaxTests = []ParseSyntaxTest{
+	{
+		Name:    `Unmatched closing parenthesis`,
+		RQL:     `eq(a,1))`,
+		WantErr: true,
+	},
+	{
+		Name:    `Operations separated by a comma`,
+		RQL:     `eq(a,1),eq(b,2)`,
+		WantErr: true,
+	},
+	{
+		Name:    `Doubled equal sign`,
+		RQL:     `a==1`,
+		WantErr: true,
+	},
+	{
+		Name:    `Parenthesized value`,
+		RQL:     `eq(a,(b))`,
+		WantErr: true,
+	},
+	{
+		Name:    `Values of different fields after an equal sign`,
+		RQL:     `a=1,b=2`,
+		WantErr: true,
+	},
+	{
+		Name:    `Trailing comma after an equal sign`,
+		RQL:     `a=1,`,
+		WantErr: true,
+	},
+	{
+		Name:     `Redundant parentheses`,
+		RQL:      `((eq(a,1)))`,
+		Expected: `eq(a,1)`,
+	},
+}
+
+func TestParseSyntax(t *testing.T) {
+	for _, test := range parseSyntaxTests {
+		test.Run(t)
+	}
+}
+
+func (test ParseSyntaxTest) Run(t *testing.T) {
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if test.WantErr {
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Code() != CodeSyntax {
+			t.Fatalf("(%s) Expecting a positioned syntax error, got: %v", test.Name, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error: %v", test.Name, err)
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+type benchModel struct {
+	Name  string  `rql:"filter,sort"`
+	Age   int     `rql:"filter,sort"`
+	Price float64 `rql:"filter"`
+}
+
+// benchQuery returns a long flat query of n operations, or a query nested n levels deep.
+// The parse time and allocations grow linearly with n.
+func benchQuery(nested bool, n int) string {
+	if nested {
+		return strings.Repeat(`and(eq(age,1),or(eq(name,foo),`, n) + `lt(price,2.5)` + strings.Repeat(`))`, n)
+	}
+	return strings.Repeat(`eq(name,foo)&gt(age,30)&in(price,[1,2,3])&`, n) + `sort(+name,-age)&limit(10,20)`
+}
+
+func runParseBenchmarks(b *testing.B, parse func(q string) error) {
+	for _, nested := range []bool{false, true} {
+		for _, n := range []int{10, 100, 1000} {
+			name := fmt.Sprintf("Flat/%d", n)
+			if nested {
+				name = fmt.Sprintf("Nested/%d", n)
+			}
+			q := benchQuery(nested, n)
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(q)))
+				for i := 0; i < b.N; i++ {
+					if err := parse(q); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	runParseBenchmarks(b, func(q string) error {
+		_, err := NewScanner().Scan(strings.NewReader(q))
+		return err
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	p, err := NewParser(&Config{Model: benchModel{}})
+	if err != nil {
+		b.Fatalf("New parser error :%v", err)
+	}
+	runParseBenchmarks(b, func(q string) error {
+		_, err := p.Parse(strings.NewReader(q))
+		return err
+	})
+}
@@ -0,0 +1,224 @@
+// Package builder constructs RQL queries programmatically. For example:
+//
+//	q := builder.Eq("price", 10).And(builder.In("status", "a", "b")).Sort("-price").Limit(20)
+//	q.String() // eq(price,10)&in(status,[a,b])&sort(-price)&limit(20)
+//
+// Queries are immutable: every method returns a new query, so a query can be safely reused
+// as the base of other queries.
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"github.com/douglaslim/gorql"
+	"strconv"
+	"strings"
+)
+
+// ErrNoValues is the error of the In queries without values. They can not be encoded, because
+// in(status,[]) is read back as the records whose status is an empty string.
+var ErrNoValues = errors.New("builder: in has no values")
+
+// Query is a filter with its sort, select, limit and offset operations.
+// The zero value is an empty query that matches everything.
+type Query struct {
+	node    *gorql.RqlNode
+	sorts   []gorql.Sort
+	selects []string
+	limit   string
+	offset  string
+	// err is the first error of the query, or of the queries it was built from.
+	err error
+}
+
+// New returns an empty query, to build queries without a filter, e.g. New().Limit(10).
+func New() *Query {
+	return &Query{}
+}
+
+// Eq returns the query of the records whose field is equal to the value.
+//
+// String values are untyped, like the values of an RQL query, and are converted to the field
+// type by the parser. Other values, like numbers, booleans, times and *regexp.Regexp, are kept
+// in the node. Numbers and booleans are encoded as plain values, while times and regular
+// expressions keep their prefix, e.g. date:2020-01-02T00:00:00Z or re:%5Efoo.
+func Eq(field string, value interface{}) *Query { return compare("eq", field, value) }
+
+// Ne returns the query of the records whose field is not equal to the value.
+func Ne(field string, value interface{}) *Query { return compare("ne", field, value) }
+
+// Gt returns the query of the records whose field is greater than the value.
+func Gt(field string, value interface{}) *Query { return compare("gt", field, value) }
+
+// Ge returns the query of the records whose field is greater than or equal to the value.
+func Ge(field string, value interface{}) *Query { return compare("ge", field, value) }
+
+// Lt returns the query of the records whose field is less than the value.
+func Lt(field string, value interface{}) *Query { return compare("lt", field, value) }
+
+// Le returns the query of the records whose field is less than or equal to the value.
+func Le(field string, value interface{}) *Query { return compare("le", field, value) }
+
+// Like returns the query of the records whose field matches the pattern, where * is a wildcard.
+func Like(field, pattern string) *Query { return compare("like", field, pattern) }
+
+// Match returns the query of the records whose field matches the pattern, ignoring the case.
+func Match(field, pattern string) *Query { return compare("match", field, pattern) }
+
+// In returns the query of the records whose field is one of the values. The query of no values
+// has the ErrNoValues error, that is reported by Err.
+func In(field string, values ...interface{}) *Query {
+	group := &gorql.RqlNode{Op: "group", Args: append([]interface{}{field}, values...)}
+	q := &Query{node: &gorql.RqlNode{Op: "in", Args: []interface{}{field, group}}}
+	if len(values) == 0 {
+		q.err = fmt.Errorf("%w: %s", ErrNoValues, field)
+	}
+	return q
+}
+
+// Op returns the query of a custom operation, like the operations registered by the drivers.
+func Op(op string, args ...interface{}) *Query {
+	return &Query{node: &gorql.RqlNode{Op: op, Args: args}}
+}
+
+// Not returns the query of the records that do not match q.
+// The sort, select, limit and offset of q are ignored.
+func Not(q *Query) *Query {
+	if q == nil || q.node == nil {
+		return &Query{}
+	}
+	return &Query{node: &gorql.RqlNode{Op: "not", Args: []interface{}{q.node}}, err: q.err}
+}
+
+// And returns the query of the records that match all the queries.
+// The sort, select, limit and offset of the queries are ignored.
+func And(qs ...*Query) *Query { return New().And(qs...) }
+
+// Or returns the query of the records that match any of the queries.
+// The sort, select, limit and offset of the queries are ignored.
+func Or(qs ...*Query) *Query { return New().Or(qs...) }
+
+func compare(op, field string, value interface{}) *Query {
+	return &Query{node: &gorql.RqlNode{Op: op, Args: []interface{}{field, value}}}
+}
+
+// And returns the query of the records that match q and all the other queries. Nested
+// conjunctions are flattened. The sort, select, limit and offset of the other queries
+// are ignored.
+func (q *Query) And(others ...*Query) *Query {
+	return q.join("and", others)
+}
+
+// Or returns the query of the records that match q or any of the other queries. Nested
+// disjunctions are flattened. The sort, select, limit and offset of the other queries
+// are ignored.
+func (q *Query) Or(others ...*Query) *Query {
+	return q.join("or", others)
+}
+
+// join joins the filters of q and the other queries with the op operation.
+func (q *Query) join(op string, others []*Query) *Query {
+	var args []interface{}
+	var err error
+	for _, o := range append([]*Query{q}, others...) {
+		if err == nil && o != nil {
+			err = o.err
+		}
+		switch {
+		case o == nil || o.node == nil:
+		case o.node.Op == op:
+			args = append(args, o.node.Args...)
+		default:
+			args = append(args, o.node)
+		}
+	}
+	c := q.clone()
+	c.err = err
+	switch len(args) {
+	case 0:
+		c.node = nil
+	case 1:
+		c.node = args[0].(*gorql.RqlNode)
+	default:
+		c.node = &gorql.RqlNode{Op: op, Args: args}
+	}
+	return c
+}
+
+// Sort returns the query sorted by the given fields, after the existing sort fields.
+// A field prefixed with "-" is sorted in descending order, and with "+" or no prefix
+// in ascending order.
+func (q *Query) Sort(fields ...string) *Query {
+	c := q.clone()
+	for _, f := range fields {
+		s := gorql.Sort{By: strings.TrimPrefix(f, "+")}
+		if strings.HasPrefix(f, "-") {
+			s = gorql.Sort{By: f[1:], Desc: true}
+		}
+		c.sorts = append(c.sorts, s)
+	}
+	return c
+}
+
+// Select returns the query with the given fields added to its selected fields.
+func (q *Query) Select(fields ...string) *Query {
+	c := q.clone()
+	c.selects = append(c.selects, fields...)
+	return c
+}
+
+// Limit returns the query with the given limit.
+func (q *Query) Limit(n int) *Query {
+	c := q.clone()
+	c.limit = strconv.Itoa(n)
+	return c
+}
+
+// Offset returns the query with the given offset.
+func (q *Query) Offset(n int) *Query {
+	c := q.clone()
+	c.offset = strconv.Itoa(n)
+	return c
+}
+
+// Root returns the root node of the query, that can be passed to the drivers. Note that the
+// root node is not validated against a model. The returned root node does not share its
+// slices with the query.
+func (q *Query) Root() *gorql.RqlRootNode {
+	root := &gorql.RqlRootNode{Node: q.node.Clone()}
+	root.SetSort(append([]gorql.Sort(nil), q.sorts...))
+	root.SetSelects(append([]string(nil), q.selects...))
+	root.SetLimit(q.limit)
+	root.SetOffset(q.offset)
+	return root
+}
+
+// Err returns the first error of the query, or of the queries it was built from, like the
+// ErrNoValues error of In. A query with an error is not read back as it was built, and should
+// not be used.
+func (q *Query) Err() error {
+	if q == nil {
+		return nil
+	}
+	return q.err
+}
+
+// String encodes the query into its canonical RQL form, with the values escaped.
+func (q *Query) String() string {
+	return q.Root().String()
+}
+
+// clone returns a copy of q. The nodes are shared, because they are never modified.
+func (q *Query) clone() *Query {
+	if q == nil {
+		return &Query{}
+	}
+	return &Query{
+		node:    q.node,
+		sorts:   append([]gorql.Sort(nil), q.sorts...),
+		selects: append([]string(nil), q.selects...),
+		limit:   q.limit,
+		offset:  q.offset,
+		err:     q.err,
+	}
+}
@@ -0,0 +1,138 @@
+package builder
+
+import (
+	"errors"
+	"github.com/douglaslim/gorql"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Test struct {
+	Name     string // Name of the test
+	Query    *Query // Input query
+	Expected string // Expected canonical RQL
+}
+
+var tests = []Test{
+	{
+		Name:     `Fluent query`,
+		Query:    Eq("price", 10).And(In("status", "a", "b")).Sort("-price").Limit(20),
+		Expected: `eq(price,10)&in(status,[a,b])&sort(-price)&limit(20)`,
+	},
+	{
+		Name:     `Reserved characters are escaped`,
+		Query:    Eq("name", "Smith, John (Jr) & co").And(Like("title", "a+b*")),
+		Expected: `eq(name,"Smith, John (Jr) & co")&like(title,"a+b*")`,
+	},
+	{
+		Name:     `Nested conjunctions are flattened`,
+		Query:    And(Eq("a", "1"), And(Eq("b", "2"), Eq("c", "3"))).And(Eq("d", "4")),
+		Expected: `eq(a,1)&eq(b,2)&eq(c,3)&eq(d,4)`,
+	},
+	{
+		Name:     `Or and not`,
+		Query:    Or(Lt("price", 5), Gt("price", 100).Or(Not(Eq("status", "open")))),
+		Expected: `or(lt(price,5),gt(price,100),not(eq(status,open)))`,
+	},
+	{
+		Name:     `Typed values`,
+		Query:    And(Eq("active", true), Ge("created", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)), Match("name", "jo*"), Op("re", "name", regexp.MustCompile("^j"))),
+		Expected: `eq(active,true)&ge(created,date:2020-01-02T00:00:00Z)&match(name,jo*)&re(name,re:%5Ej)`,
+	},
+	{
+		Name:     `Strings that look like typed literals`,
+		Query:    Eq("code", "number:5"),
+		Expected: `eq(code,string:number:5)`,
+	},
+	{
+		Name:     `Special operations only`,
+		Query:    New().Select("name", "price").Sort("name", "+id").Offset(40),
+		Expected: `sort(+name,+id)&select(name,price)&offset(40)`,
+	},
+	{
+		Name:     `Empty query`,
+		Query:    And(),
+		Expected: ``,
+	},
+}
+
+func TestBuilder(t *testing.T) {
+	for _, test := range tests {
+		if s := test.Query.String(); s != test.Expected {
+			t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+		}
+	}
+}
+
+func TestBuilderRoundTrip(t *testing.T) {
+	p, err := gorql.NewParser(&gorql.Config{Model: new(struct {
+		Price  int    `rql:"filter,sort"`
+		Name   string `rql:"filter"`
+		Status string `rql:"filter"`
+	})})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	q := Gt("price", 10).And(Eq("name", "Smith, John"), In("status", "a", "b c")).Sort("-price").Limit(20)
+	root, err := p.Parse(strings.NewReader(q.String()))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if s := root.String(); s != q.String() {
+		t.Fatalf("Expecting RQL %s, got %s", q, s)
+	}
+}
+
+func TestBuilderRoundTripGroupField(t *testing.T) {
+	p, err := gorql.NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	q := In("my field", "a", "b c").And(Eq("my field", "d"))
+	expected := `in(my%20field,[a,"b c"])&eq("my field",d)`
+	if s := q.String(); s != expected {
+		t.Fatalf("Expecting RQL %s, got %s", expected, s)
+	}
+	root, err := p.Parse(strings.NewReader(q.String()))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if s := root.String(); s != expected {
+		t.Fatalf("Expecting RQL %s, got %s", expected, s)
+	}
+}
+
+func TestBuilderInWithoutValues(t *testing.T) {
+	q := Eq("a", "1").And(Not(In("status")))
+	if err := q.Err(); !errors.Is(err, ErrNoValues) {
+		t.Fatalf("Expecting the ErrNoValues error, got: %v", err)
+	}
+	if err := q.Sort("-a").Limit(10).Err(); !errors.Is(err, ErrNoValues) {
+		t.Fatalf("Expecting the error to be kept, got: %v", err)
+	}
+	if err := In("status", "a").Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestBuilderImmutable(t *testing.T) {
+	base := Eq("tenant", "5").Sort("name")
+	a := base.And(Eq("a", "1")).Sort("-price")
+	b := base.Limit(10)
+	if s := base.String(); s != `eq(tenant,5)&sort(+name)` {
+		t.Fatalf("Base query was modified: %s", s)
+	}
+	if s := a.String(); s != `eq(tenant,5)&eq(a,1)&sort(+name,-price)` {
+		t.Fatalf("Unexpected query: %s", s)
+	}
+	if s := b.String(); s != `eq(tenant,5)&sort(+name)&limit(10)` {
+		t.Fatalf("Unexpected query: %s", s)
+	}
+	root := a.Root()
+	root.Node.Args[0].(*gorql.RqlNode).Args[1] = "6"
+	if s := a.String(); s != `eq(tenant,5)&eq(a,1)&sort(+name,-price)` {
+		t.Fatalf("Query was modified through its root node: %s", s)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/douglaslim/gorql"
 	"github.com/douglaslim/gorql/pkg/driver"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -125,6 +126,9 @@ func (ct *Translator) Sql() (sql string, err error) {
 }
 
 var convert = AlterValueFunc(func(value interface{}) (interface{}, error) {
+	if re, ok := value.(*regexp.Regexp); ok {
+		return re.String(), nil
+	}
 	return value, nil
 })
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/douglaslim/gorql"
 	"github.com/douglaslim/gorql/pkg/driver"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +18,10 @@ type Translator struct {
 type AlterValueFunc func(interface{}) (interface{}, error)
 
 var starToRegexPatternFunc = AlterValueFunc(func(value interface{}) (interface{}, error) {
+	if re, ok := value.(*regexp.Regexp); ok {
+		// typed literals (re:pattern) are used as is.
+		return convert(re.String())
+	}
 	v, ok := value.(string)
 	if !ok {
 		return nil, fmt.Errorf("unable to convert %v to string", value)
@@ -33,11 +38,7 @@ var starToRegexPatternFunc = AlterValueFunc(func(value interface{}) (interface{}
 })
 
 var ilikePatternFunc = AlterValueFunc(func(value interface{}) (interface{}, error) {
-	v, ok := value.(string)
-	if !ok {
-		return nil, fmt.Errorf("unable to convert %v to string", value)
-	}
-	newVal, err := starToRegexPatternFunc(v)
+	newVal, err := starToRegexPatternFunc(value)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +51,8 @@ var convert = AlterValueFunc(func(value interface{}) (interface{}, error) {
 		return quote(v), nil
 	case time.Time:
 		return newDateTimeFromTime(v), nil
+	case *regexp.Regexp:
+		return quote(v.String()), nil
 	}
 	return value, nil
 })
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Translator struct {
@@ -141,7 +142,11 @@ func NewSqlTranslator(r *gorql.RqlRootNode) (st *Translator) {
 
 func (st *Translator) GetEqualityTranslatorOpFunc(op, specialOp string) driver.TranslatorOpFunc {
 	return func(n *gorql.RqlNode) (s string, err error) {
-		value, err := url.QueryUnescape(n.Args[1].(string))
+		raw, ok := n.Args[1].(string)
+		if !ok {
+			return st.GetFieldValueTranslatorFunc(op, nil)(n)
+		}
+		value, err := url.QueryUnescape(raw)
 		if err != nil {
 			return "", err
 		}
@@ -226,6 +231,11 @@ func (st *Translator) GetFieldValueTranslatorFunc(op string, valueAlterFunc Alte
 					return "", err
 				}
 				s = s + tempS
+			default:
+				if i == 0 {
+					return "", fmt.Errorf("first argument must be a valid field name (arg: %v)", v)
+				}
+				s += formatValue(v)
 			}
 
 			sep = " " + op + " "
@@ -276,3 +286,19 @@ func (st *Translator) GetOpFirstTranslatorFunc(op string, valueAlterFunc AlterSt
 func quote(s string) string {
 	return `'` + strings.Replace(s, `'`, `''`, -1) + `'`
 }
+
+// formatValue formats the typed values of the parsed query, like the values of
+// typed literals (e.g. number:10) or the values converted by the parser model.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(v))
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return quote(v.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return quote(v.String())
+	}
+	return fmt.Sprint(v)
+}
@@ -134,3 +134,21 @@ func TestParser(t *testing.T) {
 		test.Run(t)
 	}
 }
+
+func TestJSONNor(t *testing.T) {
+	p, err := gorql.NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	rqlNode, err := p.ParseJSON(strings.NewReader(`{"$nor":[{"foo":42},{"bar":"baz"}]}`))
+	if err != nil {
+		t.Fatalf("ParseJSON error: %v", err)
+	}
+	s, err := NewSqlTranslator(rqlNode).Sql()
+	if err != nil {
+		t.Fatalf("Sql error: %v", err)
+	}
+	if expected := `WHERE NOT(((foo = 42) OR (bar = 'baz')))`; s != expected {
+		t.Fatalf("Translated SQL doesn’t match the expected one %s vs %s", s, expected)
+	}
+}
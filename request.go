@@ -0,0 +1,144 @@
+package gorql
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ParseRequest parses the raw query string of the request with ParseRawQuery. The cost budget
+// of the request context, if any, is applied like in ParseContext.
+func (p *Parser) ParseRequest(r *http.Request) (*RqlRootNode, error) {
+	return p.parseRawQuery(r.Context(), r.URL.RawQuery)
+}
+
+// ParseRawQuery parses a raw, still percent-encoded, query string. Unlike ParseURL, the
+// parameters keep their original order and escaping, and the parameters that are not part
+// of the RQL query are removed before parsing, according to the QueryParam, IgnoredParams and
+// IgnoreUnknownParams options of the Config. For example, with IgnoredParams: []string{"api_key"}:
+//
+//	eq(name,foo)&api_key=secret&sort(-age) => eq(name,foo)&sort(-age)
+func (p *Parser) ParseRawQuery(raw string) (*RqlRootNode, error) {
+	return p.parseRawQuery(context.Background(), raw)
+}
+
+func (p *Parser) parseRawQuery(ctx context.Context, raw string) (*RqlRootNode, error) {
+	var parts []string
+	for _, part := range splitRawQuery(raw) {
+		name, value, isParam := rawParam(part)
+		switch {
+		case p.c != nil && p.c.QueryParam != "":
+			if isParam && name == p.c.QueryParam {
+				if v, err := url.QueryUnescape(value); err == nil {
+					value = escapeDecoded(v)
+				}
+				parts = append(parts, value)
+			}
+		case isParam && p.isForeignParam(name):
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return p.ParseContext(ctx, strings.NewReader(strings.Join(parts, "&")))
+}
+
+// isForeignParam reports whether the parameter with the given name is not part of the RQL query.
+func (p *Parser) isForeignParam(name string) bool {
+	if p.c == nil {
+		return false
+	}
+	for _, ignored := range p.c.IgnoredParams {
+		if name == ignored {
+			return true
+		}
+	}
+	if p.c.IgnoreUnknownParams && !strings.HasPrefix(name, "$") {
+		_, ok := p.fields[name]
+		return !ok
+	}
+	return false
+}
+
+// splitRawQuery splits the raw query into its top-level parameters, separated by ampersands
+// that are outside parentheses and quoted values. Empty parameters are dropped.
+func splitRawQuery(raw string) (parts []string) {
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == '&' && depth == 0:
+			if i > start {
+				parts = append(parts, raw[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(raw) {
+		parts = append(parts, raw[start:])
+	}
+	return parts
+}
+
+// escapeDecoded escapes the percent signs, plus signs and spaces of the unquoted values of
+// a decoded query, so that the percent-decoding of the scanner returns the decoded values as
+// they are. The value of the QueryParam parameter is then decoded only once, like a form value:
+// a plus sign is read as a space, and %2B as a plus sign.
+func escapeDecoded(q string) string {
+	if !strings.ContainsAny(q, "%+ ") {
+		return q
+	}
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(q); i++ {
+		switch c := q[i]; {
+		case quote != 0:
+			if c == '\\' && i+1 < len(q) {
+				b.WriteByte(c)
+				i++
+				c = q[i]
+			} else if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote = c
+			b.WriteByte(c)
+		case c == '%':
+			b.WriteString("%25")
+		case c == '+':
+			b.WriteString("%2B")
+		case c == ' ':
+			b.WriteString("%20")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// rawParam returns the decoded name and the raw value of a name=value parameter. It reports
+// false for the parameters that are RQL operations, like eq(name,foo).
+func rawParam(part string) (name, value string, ok bool) {
+	i := strings.IndexByte(part, '=')
+	if i <= 0 || strings.ContainsAny(part[:i], "()|;,") {
+		return "", "", false
+	}
+	name, err := url.QueryUnescape(part[:i])
+	if err != nil {
+		return "", "", false
+	}
+	return name, part[i+1:], true
+}
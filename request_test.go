@@ -0,0 +1,141 @@
+package gorql
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+type RawQueryTest struct {
+	Name     string  // Name of the test
+	Config   *Config // Input options of the parser
+	Query    string  // Input raw query string
+	Expected string  // Expected canonical RQL
+	WantErr  bool    // Expected parse error
+}
+
+type rawQueryModel struct {
+	Name string `rql:"filter"`
+	Age  int    `rql:"filter,sort"`
+}
+
+var rawQueryTests = []RawQueryTest{
+	{
+		Name:     `Original order and escaping`,
+		Query:    `eq(name,john%20wick)&gt(age,30)&sort(-age)`,
+		Expected: `eq(name,"john wick")&gt(age,30)&sort(-age)`,
+	},
+	{
+		Name:     `Ampersands in quoted values and parentheses`,
+		Query:    `or(eq(name,"a&b"),eq(name,c))&age=10`,
+		Expected: `or(eq(name,"a&b"),eq(name,c))&eq(age,10)`,
+	},
+	{
+		Name:    `Foreign parameters are filters by default`,
+		Query:   `eq(name,foo)&api_key=secret`,
+		WantErr: true,
+	},
+	{
+		Name:     `Ignored parameters`,
+		Config:   &Config{IgnoredParams: []string{"api_key", "trace"}},
+		Query:    `api_key=secret&eq(name,foo)&trace=1&&limit(10)`,
+		Expected: `eq(name,foo)&limit(10)`,
+	},
+	{
+		Name:    `Unknown fields of operations`,
+		Config:  &Config{IgnoreUnknownParams: true},
+		Query:   `api_key=secret&eq(unknown,1)|eq(age,2)`,
+		WantErr: true,
+	},
+	{
+		Name:     `Unknown parameters`,
+		Config:   &Config{IgnoreUnknownParams: true},
+		Query:    `name=foo&api_key=secret&age=3&sort(+age)`,
+		Expected: `eq(name,foo)&eq(age,3)&sort(+age)`,
+	},
+	{
+		Name:     `Query parameter`,
+		Config:   &Config{QueryParam: "filter"},
+		Query:    `page=2&filter=eq(name,foo)%26limit(5)&api_key=secret`,
+		Expected: `eq(name,foo)&limit(5)`,
+	},
+	{
+		Name:     `Plus sign in the query parameter`,
+		Config:   &Config{QueryParam: "filter"},
+		Query:    `filter=eq(name,john+wick)`,
+		Expected: `eq(name,"john wick")`,
+	},
+	{
+		Name:     `Encoded plus sign in the query parameter`,
+		Config:   &Config{QueryParam: "filter"},
+		Query:    `filter=eq(name,a%2Bb)%26sort(%2Bage)`,
+		Expected: `eq(name,"a+b")&sort(+age)`,
+	},
+	{
+		Name:     `Encoded percent sign in the query parameter`,
+		Config:   &Config{QueryParam: "filter"},
+		Query:    `filter=eq(name,50%2525)`,
+		Expected: `eq(name,"50%25")`,
+	},
+	{
+		Name:     `Encoded query parameter`,
+		Config:   &Config{QueryParam: "filter"},
+		Query:    `filter=eq%28name%2C%22a+%25+b%22%29`,
+		Expected: `eq(name,"a % b")`,
+	},
+	{
+		Name:     `Empty query`,
+		Config:   &Config{QueryParam: "filter"},
+		Query:    `api_key=secret`,
+		Expected: ``,
+	},
+}
+
+func TestParseRawQuery(t *testing.T) {
+	for _, test := range rawQueryTests {
+		test.Run(t)
+	}
+}
+
+func (test RawQueryTest) Run(t *testing.T) {
+	c := Config{}
+	if test.Config != nil {
+		c = *test.Config
+	}
+	c.Model = rawQueryModel{}
+	p, err := NewParser(&c)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.ParseRawQuery(test.Query)
+	if test.WantErr != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantErr, err)
+	}
+	if err != nil {
+		return
+	}
+	if s := root.String(); s != test.Expected {
+		t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	p, err := NewParser(&Config{Model: rawQueryModel{}, IgnoredParams: []string{"api_key"}})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	r := httptest.NewRequest("GET", "/users?eq(name,foo)&api_key=secret&gt(age,18)", nil)
+	root, err := p.ParseRequest(r)
+	if err != nil {
+		t.Fatalf("Parse request error :%v", err)
+	}
+	if s := root.String(); s != `eq(name,foo)&gt(age,18)` {
+		t.Fatalf("Unexpected RQL %s", s)
+	}
+	r = r.WithContext(WithCostBudget(context.Background(), 1))
+	var ce *ComplexityError
+	if _, err = p.ParseRequest(r); !errors.As(err, &ce) || ce.Limit != LimitCost {
+		t.Fatalf("Expecting the cost budget of the request context to be exceeded, got: %v", err)
+	}
+}
@@ -0,0 +1,454 @@
+package gorql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field describes a field of the model of a TypedParser.
+type Field struct {
+	// Name is the name of the field in the queries.
+	Name string
+	// ReplaceWith is the name that replaces Name in the parsed queries, if any.
+	ReplaceWith string
+	// Sortable and Filterable are set by the "sort" and "filter" options in the tag.
+	Sortable   bool
+	Filterable bool
+	// Type is the type of the struct field, with its pointers indirected.
+	Type reflect.Type
+	// Index is the index sequence of the struct field, for reflect.Value.FieldByIndex.
+	Index []int
+}
+
+// TypedParser is a parser bound to the model type T. The roots that it returns are bound
+// to T as well, so a query of one model can not be evaluated against another one.
+// Like Parser, it is safe for concurrent use by multiple goroutines.
+type TypedParser[T any] struct {
+	p *Parser
+	// fields holds the fields of T by their name and by the name that replaces them,
+	// as the parsed queries hold the replaced names.
+	fields map[string]*field
+}
+
+// NewTypedParser returns a parser for the model type T, which must be a struct type. The Model
+// of the configuration is ignored, and the other options are applied like in NewParser. For example:
+//
+//	var UserParser = gorql.NewTypedParser[User](&gorql.Config{LimitMaxValue: 200})
+func NewTypedParser[T any](c *Config) (*TypedParser[T], error) {
+	var cfg Config
+	if c != nil {
+		cfg = *c
+	}
+	cfg.Model = new(T)
+	p, err := NewParser(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	tp := &TypedParser[T]{p: p, fields: make(map[string]*field)}
+	for name, f := range p.fields {
+		tp.fields[name] = f
+		if f.ReplaceWith != "" {
+			tp.fields[f.ReplaceWith] = f
+		}
+	}
+	return tp, nil
+}
+
+// Untyped returns the underlying parser, to use the front-ends that have no typed variant.
+// The roots that it returns can be bound to T with Bind.
+func (tp *TypedParser[T]) Untyped() *Parser {
+	return tp.p
+}
+
+// Bind binds a root node that was parsed by the underlying parser to T.
+func (tp *TypedParser[T]) Bind(root *RqlRootNode) *TypedRoot[T] {
+	return &TypedRoot[T]{RqlRootNode: root, fields: tp.fields}
+}
+
+// Field returns the field of T with the given name, as used in the queries, or with the name
+// that replaces it.
+func (tp *TypedParser[T]) Field(name string) (Field, bool) {
+	f, ok := tp.p.lookupField(name)
+	if !ok {
+		return Field{}, false
+	}
+	return Field{
+		Name:        f.Name,
+		ReplaceWith: f.ReplaceWith,
+		Sortable:    f.Sortable,
+		Filterable:  f.Filterable,
+		Type:        f.Type,
+		Index:       f.Index,
+	}, true
+}
+
+// Parse is like Parser.Parse, with the root bound to T.
+func (tp *TypedParser[T]) Parse(r io.Reader) (*TypedRoot[T], error) {
+	return tp.ParseContext(context.Background(), r)
+}
+
+// ParseContext is like Parser.ParseContext, with the root bound to T.
+func (tp *TypedParser[T]) ParseContext(ctx context.Context, r io.Reader) (*TypedRoot[T], error) {
+	return tp.bind(tp.p.ParseContext(ctx, r))
+}
+
+// ParseURL is like Parser.ParseURL, with the root bound to T.
+func (tp *TypedParser[T]) ParseURL(q url.Values) (*TypedRoot[T], error) {
+	return tp.bind(tp.p.ParseURL(q))
+}
+
+// ParseRequest is like Parser.ParseRequest, with the root bound to T.
+func (tp *TypedParser[T]) ParseRequest(r *http.Request) (*TypedRoot[T], error) {
+	return tp.bind(tp.p.ParseRequest(r))
+}
+
+// ParseRawQuery is like Parser.ParseRawQuery, with the root bound to T.
+func (tp *TypedParser[T]) ParseRawQuery(raw string) (*TypedRoot[T], error) {
+	return tp.bind(tp.p.ParseRawQuery(raw))
+}
+
+func (tp *TypedParser[T]) bind(root *RqlRootNode, err error) (*TypedRoot[T], error) {
+	if err != nil {
+		return nil, err
+	}
+	return tp.Bind(root), nil
+}
+
+// TypedRoot is a root node bound to the model type T. It embeds the root node, so it can
+// be passed to the drivers with its RqlRootNode field.
+type TypedRoot[T any] struct {
+	*RqlRootNode
+	fields map[string]*field
+}
+
+// Match reports whether the value matches the filter of the query. The eq, ne, gt, ge, lt, le,
+// like, match, in, and, or and not operations are supported, and an error is returned for the
+// other operations. The operations on a slice field match if any of its elements matches.
+func (r *TypedRoot[T]) Match(v T) (bool, error) {
+	if r.RqlRootNode == nil || r.Node == nil {
+		return true, nil
+	}
+	return r.match(r.Node, reflect.ValueOf(&v).Elem())
+}
+
+// Filter returns the values that match the filter of the query, in their original order.
+func (r *TypedRoot[T]) Filter(vs []T) ([]T, error) {
+	var out []T
+	for _, v := range vs {
+		ok, err := r.Match(v)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// Apply returns the values that match the filter of the query, sorted by its sort fields,
+// and paginated with its offset and limit.
+func (r *TypedRoot[T]) Apply(vs []T) ([]T, error) {
+	out, err := r.Filter(vs)
+	if err != nil || r.RqlRootNode == nil {
+		return out, err
+	}
+	if sorts := r.Sort(); len(sorts) > 0 {
+		keys := make([]*field, len(sorts))
+		for i, s := range sorts {
+			if keys[i] = r.fields[s.By]; keys[i] == nil {
+				return nil, fmt.Errorf("rql: unknown sort field %q", s.By)
+			}
+		}
+		sort.SliceStable(out, func(i, j int) bool {
+			a, b := reflect.ValueOf(&out[i]).Elem(), reflect.ValueOf(&out[j]).Elem()
+			for k, s := range sorts {
+				c := compareFields(fieldValue(a, keys[k]), fieldValue(b, keys[k]))
+				if c != 0 {
+					return (c < 0) != s.Desc
+				}
+			}
+			return false
+		})
+	}
+	if o := r.Offset(); o != "" {
+		offset, err := strconv.Atoi(o)
+		if err != nil {
+			return nil, fmt.Errorf("rql: invalid offset %q", o)
+		}
+		if offset > len(out) {
+			offset = len(out)
+		}
+		out = out[offset:]
+	}
+	if l := r.Limit(); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil {
+			return nil, fmt.Errorf("rql: invalid limit %q", l)
+		}
+		if limit < len(out) {
+			out = out[:limit]
+		}
+	}
+	return out, nil
+}
+
+// match evaluates the node against the struct value v.
+func (r *TypedRoot[T]) match(n *RqlNode, v reflect.Value) (bool, error) {
+	switch op := strings.ToLower(n.Op); op {
+	case "and", "or", "not":
+		matched := false
+		for _, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return false, fmt.Errorf("rql: invalid argument %v of %s operation", a, n.Op)
+			}
+			m, err := r.match(c, v)
+			if err != nil {
+				return false, err
+			}
+			if op == "and" && !m {
+				return false, nil
+			}
+			matched = matched || m
+		}
+		if op == "and" {
+			return true, nil
+		}
+		return matched != (op == "not"), nil
+	case "eq", "ne", "gt", "ge", "lt", "le", "like", "match", "in":
+		if len(n.Args) != 2 {
+			return false, fmt.Errorf("rql: %s operation expects 2 arguments, got %d", n.Op, len(n.Args))
+		}
+		name, _ := n.Args[0].(string)
+		f := r.fields[name]
+		if f == nil {
+			return false, fmt.Errorf("rql: unknown field %q in %s operation", name, n.Op)
+		}
+		values := fieldValues(v, f)
+		if (op == "eq" || op == "ne") && n.Args[1] == "null" {
+			// like the drivers, null is compared with IS NULL and IS NOT NULL.
+			return (len(values) == 0) == (op == "eq"), nil
+		}
+		if op == "ne" {
+			m, err := matchAny(values, "eq", n.Args[1])
+			return !m, err
+		}
+		return matchAny(values, op, n.Args[1])
+	default:
+		return false, fmt.Errorf("rql: operation %q is not supported in memory", n.Op)
+	}
+}
+
+// matchAny reports whether any of the field values matches the operation with the argument.
+func matchAny(values []interface{}, op string, arg interface{}) (bool, error) {
+	var pattern *regexp.Regexp
+	if op == "like" || op == "match" {
+		var err error
+		if pattern, err = patternOf(arg, op == "match"); err != nil {
+			return false, err
+		}
+	}
+	for _, x := range values {
+		var m bool
+		switch op {
+		case "eq":
+			m = compareFields(x, arg) == 0
+		case "gt", "ge", "lt", "le":
+			c, ok := compareOrdered(x, arg)
+			m = ok && ((op == "gt" && c > 0) || (op == "ge" && c >= 0) || (op == "lt" && c < 0) || (op == "le" && c <= 0))
+		case "like", "match":
+			s, ok := x.(string)
+			m = ok && pattern.MatchString(s)
+		case "in":
+			group, ok := arg.(*RqlNode)
+			if !ok || !isGroupOp(group.Op) {
+				return false, fmt.Errorf("rql: in operation expects a list of values, got %v", arg)
+			}
+			for _, a := range group.Args[1:] {
+				if m = compareFields(x, a) == 0; m {
+					break
+				}
+			}
+		}
+		if m {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// patternOf returns the regular expression of a like or match pattern, where * is a wildcard.
+// Regular expression values are used as is.
+func patternOf(arg interface{}, ignoreCase bool) (*regexp.Regexp, error) {
+	switch v := arg.(type) {
+	case *regexp.Regexp:
+		return v, nil
+	case string:
+		parts := strings.Split(v, "*")
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		expr := "^" + strings.Join(parts, ".*") + "$"
+		if ignoreCase {
+			expr = "(?i)" + expr
+		}
+		return regexp.Compile(expr)
+	}
+	return nil, fmt.Errorf("rql: invalid pattern %v", arg)
+}
+
+// compareOrdered compares two values, and reports whether they are ordered.
+// Strings are compared lexically.
+func compareOrdered(a, b interface{}) (int, bool) {
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+		return 0, false
+	}
+	return compareValues(a, b)
+}
+
+// compareFields compares two values for sorting and equality. The values that are not ordered
+// are equal if they are equal, and nil values come first.
+func compareFields(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if c, ok := compareOrdered(a, b); ok {
+		return c
+	}
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	}
+	if equal, _ := equalValues(a, b); equal {
+		return 0
+	}
+	return 1
+}
+
+// fieldValues returns the values of the field in the struct value v. Slices have a value per
+// element, and the fields that are nil have none.
+func fieldValues(v reflect.Value, f *field) []interface{} {
+	fv, ok := fieldByIndex(v, f.Index)
+	if !ok {
+		return nil
+	}
+	if fv.Kind() == reflect.Slice {
+		values := make([]interface{}, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			if x := scalarOf(fv.Index(i)); x != nil {
+				values = append(values, x)
+			}
+		}
+		return values
+	}
+	if x := scalarOf(fv); x != nil {
+		return []interface{}{x}
+	}
+	return nil
+}
+
+// fieldValue returns the value of the field for sorting, or nil if it has none.
+func fieldValue(v reflect.Value, f *field) interface{} {
+	values := fieldValues(v, f)
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false instead of
+// panicking on nil pointers.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// scalarOf returns the value of a field in the types that the parser converts the filter
+// values to, or nil if the field is null.
+func scalarOf(v reflect.Value) interface{} {
+	var x interface{}
+	if v.CanInterface() {
+		x = v.Interface()
+	}
+	switch x := x.(type) {
+	case time.Time:
+		return x
+	case sql.NullString:
+		if x.Valid {
+			return x.String
+		}
+		return nil
+	case sql.NullInt64:
+		if x.Valid {
+			return x.Int64
+		}
+		return nil
+	case sql.NullFloat64:
+		if x.Valid {
+			return x.Float64
+		}
+		return nil
+	case sql.NullBool:
+		if x.Valid {
+			return x.Bool
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Struct:
+		if t := reflect.TypeOf(time.Time{}); v.Type().ConvertibleTo(t) {
+			return v.Convert(t).Interface()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package gorql
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type typedAddress struct {
+	City string `rql:"filter,sort"`
+}
+
+type typedUser struct {
+	Name     string         `rql:"filter,sort"`
+	Age      int            `rql:"filter,sort"`
+	Score    *float64       `rql:"filter"`
+	Admin    bool           `rql:"filter"`
+	Tags     []string       `rql:"filter"`
+	Nick     sql.NullString `rql:"filter,column=nickname"`
+	Created  time.Time      `rql:"filter,sort"`
+	Address  typedAddress
+	Internal string `rql:"filter,replacewith=internal_name"`
+}
+
+func typedUsers() []typedUser {
+	score := 9.5
+	return []typedUser{
+		{Name: "alice", Age: 30, Score: &score, Tags: []string{"a", "b"}, Address: typedAddress{City: "Zürich"}, Created: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "bob", Age: 25, Admin: true, Nick: sql.NullString{String: "bobby", Valid: true}, Address: typedAddress{City: "Paris"}, Created: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "Carol", Age: 40, Tags: []string{"c"}, Address: typedAddress{City: "Paris"}, Internal: "x", Created: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "dave", Age: 25, Address: typedAddress{City: "Berlin"}, Created: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+type TypedTest struct {
+	Name     string   // Name of the test
+	RQL      string   // Input RQL query
+	Expected []string // Expected names of the users, in order
+	WantErr  bool     // Expected evaluation error
+}
+
+var typedTests = []TypedTest{
+	{
+		Name:     `Comparisons`,
+		RQL:      `ge(age,30)`,
+		Expected: []string{"alice", "Carol"},
+	},
+	{
+		Name:     `Logical operations`,
+		RQL:      `or(eq(name,bob),and(gt(age,20),not(eq(addressCity,Paris))))`,
+		Expected: []string{"alice", "bob", "dave"},
+	},
+	{
+		Name:     `In and slices`,
+		RQL:      `in(tags,[b,c])`,
+		Expected: []string{"alice", "Carol"},
+	},
+	{
+		Name:     `Not equal on slices`,
+		RQL:      `ne(tags,a)`,
+		Expected: []string{"bob", "Carol", "dave"},
+	},
+	{
+		Name:     `Like and match`,
+		RQL:      `like(name,*o*)|match(name,c*)`,
+		Expected: []string{"bob", "Carol"},
+	},
+	{
+		Name:     `Pointers and nulls`,
+		RQL:      `gt(score,5)|eq(nickname,bobby)`,
+		Expected: []string{"alice", "bob"},
+	},
+	{
+		Name:     `Equal to null`,
+		RQL:      `eq(nickname,null)|eq(name,null)`,
+		Expected: []string{"alice", "Carol", "dave"},
+	},
+	{
+		Name:     `Not equal to null`,
+		RQL:      `ne(nickname,null)&ne(name,null)`,
+		Expected: []string{"bob"},
+	},
+	{
+		Name:     `Booleans and times`,
+		RQL:      `eq(admin,false)&lt(created,2021-06-01T00:00:00Z)`,
+		Expected: []string{"alice", "Carol"},
+	},
+	{
+		Name:     `Replaced field names`,
+		RQL:      `eq(internal,x)`,
+		Expected: []string{"Carol"},
+	},
+	{
+		Name:     `Sort, offset and limit`,
+		RQL:      `sort(+age,-name)&limit(2,1)`,
+		Expected: []string{"bob", "alice"},
+	},
+	{
+		Name:     `Sort by time`,
+		RQL:      `gt(age,0)&sort(-created)`,
+		Expected: []string{"dave", "bob", "alice", "Carol"},
+	},
+	{
+		Name:    `Unsupported operation`,
+		RQL:     `custom(name,foo)`,
+		WantErr: true,
+	},
+}
+
+func TestTypedParser(t *testing.T) {
+	for _, test := range typedTests {
+		test.Run(t)
+	}
+}
+
+func (test TypedTest) Run(t *testing.T) {
+	p, err := NewTypedParser[typedUser](nil)
+	if err != nil {
+		t.Fatalf("(%s) New parser error :%v\n", test.Name, err)
+	}
+	root, err := p.Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Parse error :%v\n", test.Name, err)
+	}
+	users, err := root.Apply(typedUsers())
+	if test.WantErr != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantErr, err)
+	}
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	if !reflect.DeepEqual(names, test.Expected) {
+		t.Fatalf("(%s) Expecting users %v, got %v", test.Name, test.Expected, names)
+	}
+}
+
+func TestTypedParserField(t *testing.T) {
+	p, err := NewTypedParser[typedUser](&Config{Model: struct{}{}})
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	f, ok := p.Field("addressCity")
+	if !ok || !f.Sortable || f.Type.Kind() != reflect.String {
+		t.Fatalf("Unexpected field: %+v", f)
+	}
+	if sf := reflect.TypeOf(typedUser{}).FieldByIndex(f.Index); sf.Name != "City" {
+		t.Fatalf("Unexpected index of the field: %v", f.Index)
+	}
+	if f, ok := p.Field("internal_name"); !ok || f.Name != "internal" {
+		t.Fatalf("Expecting the field of the replaced name, got: %+v", f)
+	}
+	if _, ok := p.Field("city"); ok {
+		t.Fatal("Expecting no field named city")
+	}
+	if _, err := NewTypedParser[int](nil); err == nil {
+		t.Fatal("Expecting an error for a model that is not a struct")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"regexp"
 	"strconv"
 	"time"
 )
@@ -82,98 +83,193 @@ func (p *Parser) validateFields(n *RqlNode) error {
 	if n == nil {
 		return nil
 	}
-	fn := p.GetFieldValidationFunc()
-	if fn == nil {
-		return fmt.Errorf("no field validation op '%s'", n.Op)
-	}
-	return fn(n)
+	var errs ErrorList
+	p.validateNode(n, &errs)
+	return p.errorsOf(errs)
 }
 
+// GetFieldValidationFunc returns the function that validates the node fields against the model,
+// and converts their values to the field types.
 func (p *Parser) GetFieldValidationFunc() ValidationFunc {
-	return func(n *RqlNode) (err error) {
-		var field *field
-		for i, a := range n.Args {
-			switch v := a.(type) {
-			case string:
-				if i == 0 {
-					f, ok := p.fields[v]
-					if !ok || !f.Filterable {
-						return fmt.Errorf("field name (arg: %s) is not filterable", v)
-					}
+	return p.validateFields
+}
+
+// validateNode validates the arguments of n and its children, and appends the errors to errs.
+// It returns false if the validation should stop.
+func (p *Parser) validateNode(n *RqlNode, errs *ErrorList) bool {
+	var field *field
+	for i, a := range n.Args {
+		var err error
+		switch v := a.(type) {
+		case string:
+			if i == 0 {
+				f, ok := p.fields[v]
+				if !ok {
+					err = &UnknownFieldError{Field: v, Op: n.Op}
+				} else if !f.Filterable {
+					err = &NotFilterableError{Field: v, Op: n.Op}
+				} else {
 					field = f
 					if field.ReplaceWith != "" {
 						n.Args[i] = field.ReplaceWith
 					}
+				}
+				if err != nil {
+					// the values of an invalid field can not be validated.
+					return p.collect(errs, &ParseError{Pos: n.Pos, Err: err})
+				}
+			} else {
+				if field == nil {
+					err = &InvalidValueError{Op: n.Op, Value: v, Err: errors.New("no field is found for node value")}
+				} else if newVal, cerr := field.CovertFn(v); cerr != nil {
+					err = &InvalidValueError{Field: field.Name, Op: n.Op, Value: v, Err: cerr}
 				} else {
-					if field == nil {
-						return fmt.Errorf("no field is found for node value %s", v)
-					}
-					newVal, err := field.CovertFn(v)
-					if err != nil {
-						return fmt.Errorf("encounter field error: %s", err)
-					}
 					n.Args[i] = newVal
 				}
-			case *RqlNode:
-				err = p.validateFields(v)
-				if err != nil {
-					return err
+				if err != nil && !p.collect(errs, &ParseError{Pos: n.Pos, Err: err}) {
+					return false
 				}
 			}
+		case *RqlNode:
+			if !p.validateNode(v, errs) {
+				return false
+			}
+		default:
+			if i == 0 || field == nil {
+				continue
+			}
+			if newVal, cerr := convertTyped(field, v); cerr != nil {
+				err = &InvalidValueError{Field: field.Name, Op: n.Op, Value: encodeArg(v), Err: cerr}
+			} else {
+				n.Args[i] = newVal
+			}
+			if err != nil && !p.collect(errs, &ParseError{Pos: n.Pos, Err: err}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// convertTyped validates the value of a typed literal against the field type, and converts it
+// to the type that the field conversion produces. For example, a number is converted to int for
+// int fields, and is not valid for string fields.
+func convertTyped(f *field, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case time.Time:
+		if !f.Type.ConvertibleTo(reflect.TypeOf(time.Time{})) {
+			return nil, fmt.Errorf("expect <%s>, got <time>", f.Type.Kind())
+		}
+		return v, nil
+	case *regexp.Regexp:
+		// patterns are valid only for string fields.
+		if err := f.ValidateFn(v.String()); err != nil {
+			return nil, errorType(v, f.Type.Kind().String())
 		}
+		return v, nil
+	case float64:
+		if err := f.ValidateFn(v); err != nil {
+			return nil, err
+		}
+		return f.CovertFn(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		if err := f.ValidateFn(v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// collect appends err to errs, and reports whether the validation should continue.
+func (p *Parser) collect(errs *ErrorList, err error) bool {
+	*errs = append(*errs, err)
+	return p.c != nil && p.c.AllErrors
+}
+
+// errorsOf returns the error that describes the collected errors. It is nil if there are
+// no errors, the ErrorList in AllErrors mode, or the first error otherwise.
+func (p *Parser) errorsOf(errs ErrorList) error {
+	switch {
+	case len(errs) == 0:
 		return nil
+	case p.c != nil && p.c.AllErrors:
+		return errs
+	default:
+		return errs[0]
 	}
 }
 
-func (p *Parser) validateSpecialOps(r *RqlRootNode) error {
+// validate validates the special operations and the filter nodes of the root node,
+// and converts the filter values to the types of their fields.
+func (p *Parser) validate(r *RqlRootNode) error {
+	var errs ErrorList
+	if p.validateRoot(r, &errs) && p.c != nil && r.Node != nil {
+		p.validateNode(r.Node, &errs)
+	}
+	return p.errorsOf(errs)
+}
+
+// validateRoot validates the special operations of the root node, and appends the errors to errs.
+// It returns false if the validation should stop.
+func (p *Parser) validateRoot(r *RqlRootNode, errs *ErrorList) bool {
 	if r.Limit() != "" {
 		err := p.validateLimit(r.Limit())
-		if err != nil {
-			return err
+		if err != nil && !p.collect(errs, err) {
+			return false
 		}
 	}
 	if r.Offset() != "" {
 		err := p.validateOffset(r.Offset())
-		if err != nil {
-			return err
+		if err != nil && !p.collect(errs, err) {
+			return false
 		}
 	}
 	if p.c != nil && len(r.Sort()) > 0 {
-		err := p.validateSort(r.Sort())
-		if err != nil {
-			return err
+		for _, err := range p.validateSort(r.Sort()) {
+			if !p.collect(errs, err) {
+				return false
+			}
 		}
 	}
 	if p.c != nil && len(r.Selects()) > 0 {
-		fieldNames, err := p.validateSelects(r.Selects())
-		if err != nil {
-			return err
+		fieldNames, selectErrs := p.validateSelects(r.Selects())
+		for _, err := range selectErrs {
+			if !p.collect(errs, err) {
+				return false
+			}
+		}
+		if len(selectErrs) == 0 {
+			r.selects = fieldNames
 		}
-		r.selects = fieldNames
 	}
-	return nil
+	return true
 }
 
-func (p *Parser) validateSort(sortItems []Sort) error {
+func (p *Parser) validateSort(sortItems []Sort) (errs []error) {
 	for i, s := range sortItems {
 		f, ok := p.fields[s.By]
-		if !ok || !f.Sortable {
-			return fmt.Errorf("field %s is not sortable", s.By)
+		if !ok {
+			errs = append(errs, &UnknownFieldError{Field: s.By, Op: SortOp})
+			continue
+		}
+		if !f.Sortable {
+			errs = append(errs, &NotSortableError{Field: s.By})
+			continue
 		}
 		if f.ReplaceWith != "" {
 			sortItems[i].By = f.ReplaceWith
 		}
 	}
-	return nil
+	return
 }
 
 func (p *Parser) validateOffset(o string) error {
 	offset, err := strconv.Atoi(o)
 	if err != nil {
-		return fmt.Errorf("invalid format for offset: %s", err)
+		return &InvalidValueError{Op: OffsetOp, Value: o, Err: errors.New("invalid format for offset")}
 	}
 	if offset < 0 {
-		return fmt.Errorf("offset is less than zero")
+		return &InvalidValueError{Op: OffsetOp, Value: o, Err: errors.New("offset is less than zero")}
 	}
 	return nil
 }
@@ -181,22 +277,23 @@ func (p *Parser) validateOffset(o string) error {
 func (p *Parser) validateLimit(l string) error {
 	limit, err := strconv.Atoi(l)
 	if err != nil {
-		return fmt.Errorf("invalid format for limit: %s", err)
+		return &InvalidValueError{Op: LimitOp, Value: l, Err: errors.New("invalid format for limit")}
 	}
 	if limit < 0 {
-		return fmt.Errorf("specified limit is less than zero")
+		return &InvalidValueError{Op: LimitOp, Value: l, Err: errors.New("specified limit is less than zero")}
 	}
 	if p.c != nil && limit > p.c.LimitMaxValue {
-		return fmt.Errorf("specified limit is more than the max limit %d allowed", p.c.LimitMaxValue)
+		return &LimitExceededError{Limit: limit, Max: p.c.LimitMaxValue}
 	}
 	return nil
 }
 
-func (p *Parser) validateSelects(selects []string) (fieldNames []string, err error) {
+func (p *Parser) validateSelects(selects []string) (fieldNames []string, errs []error) {
 	for _, s := range selects {
 		f, ok := p.fields[s]
 		if !ok {
-			return nil, fmt.Errorf("field %s is projectable", s)
+			errs = append(errs, &UnknownFieldError{Field: s, Op: SelectOp})
+			continue
 		}
 		fieldName := f.Name
 		if f.ReplaceWith != "" {
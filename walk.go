@@ -0,0 +1,151 @@
+package gorql
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node *RqlNode) (w Visitor)
+}
+
+// Walk traverses the node tree in depth-first order. It starts by calling v.Visit(node);
+// node must not be nil. If the visitor w returned by v.Visit(node) is not nil, Walk is
+// invoked recursively with visitor w for each of the node arguments that are nodes,
+// followed by a call of w.Visit(nil).
+func Walk(v Visitor, node *RqlNode) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, a := range node.Args {
+		if n, ok := a.(*RqlNode); ok && n != nil {
+			Walk(v, n)
+		}
+	}
+	v.Visit(nil)
+}
+
+type inspector func(*RqlNode) bool
+
+func (f inspector) Visit(node *RqlNode) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the node tree in depth-first order. It starts by calling f(node);
+// node must not be nil. If f returns true, Inspect invokes f recursively for each of
+// the node arguments that are nodes, followed by a call of f(nil).
+func Inspect(node *RqlNode, f func(*RqlNode) bool) {
+	Walk(inspector(f), node)
+}
+
+// A Cursor describes a node encountered during Rewrite.
+// Information about the node and its parent is available from the Node, Parent,
+// Index and Path methods. The Replace, Wrap and Delete methods modify the tree.
+type Cursor struct {
+	parent  *RqlNode
+	node    *RqlNode
+	index   int
+	path    []int
+	descend *RqlNode
+	deleted bool
+}
+
+// Node returns the current node.
+func (c *Cursor) Node() *RqlNode { return c.node }
+
+// Parent returns the parent of the current node, or nil for the root node.
+func (c *Cursor) Parent() *RqlNode { return c.parent }
+
+// Index returns the index of the current node in the arguments of its parent,
+// or -1 for the root node.
+func (c *Cursor) Index() int { return c.index }
+
+// Path returns the indexes of the arguments that lead from the root node to the
+// current node. It is empty for the root node.
+func (c *Cursor) Path() []int { return append([]int(nil), c.path...) }
+
+// Replace replaces the current node with n. If it is called from the pre function,
+// the arguments of n are traversed instead of the arguments of the replaced node.
+func (c *Cursor) Replace(n *RqlNode) {
+	c.set(n)
+	c.descend = n
+}
+
+// Wrap replaces the current node with a new node of the given operation, whose
+// arguments are the current node followed by args. For example, Wrap("not") negates
+// the current node. If it is called from the pre function, the arguments of the
+// wrapped node are still traversed, but the wrapper itself is not.
+func (c *Cursor) Wrap(op string, args ...interface{}) {
+	c.set(&RqlNode{Op: op, Args: append([]interface{}{c.node}, args...), Pos: c.node.Pos})
+}
+
+// Delete removes the current node from the arguments of its parent.
+// If the current node is the root node, Rewrite returns nil.
+func (c *Cursor) Delete() {
+	c.deleted = true
+	c.node = nil
+	c.descend = nil
+	if c.parent != nil {
+		c.parent.Args = append(c.parent.Args[:c.index], c.parent.Args[c.index+1:]...)
+	}
+}
+
+func (c *Cursor) set(n *RqlNode) {
+	c.node = n
+	if c.parent != nil {
+		c.parent.Args[c.index] = n
+	}
+}
+
+// Rewrite traverses the node tree recursively, calling pre and post for each node,
+// and returns the possibly modified root. Either of pre and post may be nil.
+//
+// If pre is not nil, it is called for each node before the node arguments are traversed
+// (pre-order). If pre returns false, no arguments are traversed, and post is not called
+// for that node. If post is not nil, and a prior call of pre didn't return false, post is
+// called for each node after its arguments are traversed (post-order). If post returns
+// false, the traversal is terminated and Rewrite returns immediately.
+//
+// The tree is modified in place, the cursor methods can be used to replace, wrap or delete
+// the current node. Only the node arguments of type *RqlNode are traversed.
+func Rewrite(root *RqlNode, pre, post func(*Cursor) bool) *RqlNode {
+	if root == nil {
+		return nil
+	}
+	r := &rewriter{pre: pre, post: post}
+	c := &Cursor{node: root, index: -1, descend: root}
+	r.apply(c)
+	return c.node
+}
+
+type rewriter struct {
+	pre, post func(*Cursor) bool
+	aborted   bool
+}
+
+// apply calls the rewrite functions on the cursor node and its arguments.
+func (r *rewriter) apply(c *Cursor) {
+	if r.pre != nil && !r.pre(c) {
+		return
+	}
+	if n := c.descend; n != nil {
+		for i := 0; i < len(n.Args) && !r.aborted; i++ {
+			child, ok := n.Args[i].(*RqlNode)
+			if !ok || child == nil {
+				continue
+			}
+			cc := &Cursor{parent: n, node: child, index: i, path: append(c.Path(), i), descend: child}
+			r.apply(cc)
+			if cc.deleted {
+				i--
+			}
+		}
+	}
+	if r.aborted || c.deleted {
+		return
+	}
+	if r.post != nil && !r.post(c) {
+		r.aborted = true
+	}
+}
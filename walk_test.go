@@ -0,0 +1,139 @@
+package gorql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, q string) *RqlRootNode {
+	t.Helper()
+	p, err := NewParser(nil)
+	if err != nil {
+		t.Fatalf("New parser error :%v", err)
+	}
+	root, err := p.Parse(strings.NewReader(q))
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", q, err)
+	}
+	return root
+}
+
+func TestInspect(t *testing.T) {
+	root := mustParse(t, `and(eq(foo,42),or(gt(price,10),not(eq(disabled,true))))`)
+	var ops []string
+	Inspect(root.Node, func(n *RqlNode) bool {
+		if n != nil {
+			ops = append(ops, n.Op)
+		}
+		return n == nil || n.Op != "not"
+	})
+	expected := []string{"and", "eq", "or", "gt", "not"}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Fatalf("Expecting ops %v, got %v", expected, ops)
+	}
+}
+
+type RewriteTest struct {
+	Name     string             // Name of the test
+	RQL      string             // Input RQL query
+	Pre      func(*Cursor) bool // Rewrite pre function
+	Post     func(*Cursor) bool // Rewrite post function
+	Expected string             // Expected RQL after rewrite
+}
+
+var rewriteTests = []RewriteTest{
+	{
+		Name: `Rename field`,
+		RQL:  `and(eq(name,foo),or(gt(price,10),like(name,bar*)))`,
+		Pre: func(c *Cursor) bool {
+			if n := c.Node(); len(n.Args) > 0 && n.Args[0] == "name" {
+				n.Args[0] = "full_name"
+			}
+			return true
+		},
+		Expected: `eq(full_name,foo)&or(gt(price,10),like(full_name,bar*))`,
+	},
+	{
+		Name: `Inject tenant filter`,
+		RQL:  `or(eq(a,1),eq(b,2))`,
+		Post: func(c *Cursor) bool {
+			if c.Parent() == nil {
+				c.Wrap("and", &RqlNode{Op: "eq", Args: []interface{}{"tenant", "5"}})
+			}
+			return true
+		},
+		Expected: `or(eq(a,1),eq(b,2))&eq(tenant,5)`,
+	},
+	{
+		Name: `Drop banned operator`,
+		RQL:  `and(eq(a,1),match(b,*x*),or(match(c,y),eq(d,2)))`,
+		Pre: func(c *Cursor) bool {
+			if c.Node().Op == "match" {
+				c.Delete()
+			}
+			return true
+		},
+		Expected: `eq(a,1)&or(eq(d,2))`,
+	},
+	{
+		Name: `Wrap in pre traverses the wrapped node`,
+		RQL:  `and(eq(a,1),eq(b,2))`,
+		Pre: func(c *Cursor) bool {
+			switch n := c.Node(); {
+			case n.Op == "eq" && n.Args[0] == "a":
+				c.Wrap("not")
+			case n.Op == "eq":
+				c.Replace(&RqlNode{Op: "ne", Args: n.Args})
+			}
+			return true
+		},
+		Expected: `not(eq(a,1))&ne(b,2)`,
+	},
+	{
+		Name: `Delete root`,
+		RQL:  `eq(a,1)`,
+		Pre: func(c *Cursor) bool {
+			c.Delete()
+			return true
+		},
+		Expected: ``,
+	},
+	{
+		Name: `Abort traversal`,
+		RQL:  `and(eq(a,1),eq(b,2))`,
+		Post: func(c *Cursor) bool {
+			c.Node().Op = "ne"
+			return false
+		},
+		Expected: `ne(a,1)&eq(b,2)`,
+	},
+}
+
+func TestRewrite(t *testing.T) {
+	for _, test := range rewriteTests {
+		root := mustParse(t, test.RQL)
+		root.Node = Rewrite(root.Node, test.Pre, test.Post)
+		if s := root.String(); s != test.Expected {
+			t.Fatalf("(%s) Expecting RQL %s, got %s", test.Name, test.Expected, s)
+		}
+	}
+}
+
+func TestRewritePath(t *testing.T) {
+	root := mustParse(t, `and(eq(a,1),or(eq(b,2),eq(c,3)))`)
+	paths := map[string][]int{}
+	Rewrite(root.Node, func(c *Cursor) bool {
+		if n := c.Node(); n.Op == "eq" {
+			paths[n.Args[0].(string)] = c.Path()
+			if c.Parent().Args[c.Index()] != n {
+				t.Fatalf("Index %d does not point to the current node", c.Index())
+			}
+		}
+		return true
+	}, nil)
+	expected := map[string][]int{"a": {0}, "b": {1, 0}, "c": {1, 1}}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expecting paths %v, got %v", expected, paths)
+	}
+}